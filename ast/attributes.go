@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"slices"
+	"strings"
+)
+
+// Attribute is a key/value pair attached to a node from a {...} attribute
+// list. Ids and classes are stored under the "id" and "class" keys.
+type Attribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// IsValidAttributeKey reports whether key can be written as an HTML
+// attribute name: a letter, _ or : followed by letters, digits, _, ., : or -.
+func IsValidAttributeKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case i > 0 && (c >= '0' && c <= '9' || c == '.' || c == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IsEventHandlerKey reports whether key names an event handler such as
+// onclick, which renderers drop unless told otherwise.
+func IsEventHandlerKey(key string) bool {
+	return len(key) > 2 && strings.EqualFold(key[:2], "on")
+}
+
+// LookupAttribute returns the value of the first attribute named key.
+func LookupAttribute(attrs []Attribute, key string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Attrs returns the node's generic attributes in the order they were first
+// set. Renderers emit them on the node's element, merging classes with any
+// the element already has.
+func (n *BaseNode) Attrs() []Attribute {
+	return slices.Clone(n.attrs)
+}
+
+// Attr returns the value of the attribute named key.
+func (n *BaseNode) Attr(key string) (string, bool) {
+	return LookupAttribute(n.attrs, key)
+}
+
+// SetAttr sets the attribute named key, keeping its place if it is already
+// set and appending it otherwise.
+func (n *BaseNode) SetAttr(key, value string) {
+	for i := range n.attrs {
+		if n.attrs[i].Key == key {
+			n.attrs[i].Value = value
+			return
+		}
+	}
+	n.attrs = append(n.attrs, Attribute{Key: key, Value: value})
+}
+
+// DeleteAttr removes the attribute named key.
+func (n *BaseNode) DeleteAttr(key string) {
+	n.attrs = slices.DeleteFunc(n.attrs, func(attr Attribute) bool {
+		return attr.Key == key
+	})
+}
+
+// Data returns the extension data stored under key. Prefer a DataKey, which
+// gives typed access.
+func (n *BaseNode) Data(key any) (any, bool) {
+	value, ok := n.data[key]
+	return value, ok
+}
+
+// SetData stores extension data under key, which must be comparable.
+func (n *BaseNode) SetData(key, value any) {
+	if n.data == nil {
+		n.data = make(map[any]any)
+	}
+	n.data[key] = value
+}
+
+// DeleteData removes the extension data stored under key.
+func (n *BaseNode) DeleteData(key any) {
+	delete(n.data, key)
+}
+
+// DataKey gives typed access to extension data on nodes. Each key is
+// distinct, so extensions cannot overwrite each other's data by accident:
+//
+//	var wordCount = ast.NewDataKey[int]("wordcount")
+//
+//	wordCount.Set(paragraph, 42)
+//	n, ok := wordCount.Get(paragraph)
+type DataKey[T any] struct {
+	name string
+}
+
+// NewDataKey returns a new key. The name is only used for debugging.
+func NewDataKey[T any](name string) *DataKey[T] {
+	return &DataKey[T]{name: name}
+}
+
+// Get returns the value stored on node under k.
+func (k *DataKey[T]) Get(node Node) (T, bool) {
+	value, ok := node.Data(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// Set stores value on node under k.
+func (k *DataKey[T]) Set(node Node, value T) {
+	node.SetData(k, value)
+}
+
+// Delete removes the value stored on node under k.
+func (k *DataKey[T]) Delete(node Node) {
+	node.DeleteData(k)
+}
+
+// String returns the name of the key.
+func (k *DataKey[T]) String() string {
+	return k.name
+}
@@ -0,0 +1,66 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodeAttributes(t *testing.T) {
+	p := NewParagraph()
+	if attrs := p.Attrs(); len(attrs) != 0 {
+		t.Fatalf("expected no attributes, got %v", attrs)
+	}
+
+	p.SetAttr("id", "intro")
+	p.SetAttr("class", "lead")
+	p.SetAttr("data-x", "1")
+	p.SetAttr("id", "summary")
+	p.DeleteAttr("class")
+
+	expected := []Attribute{{"id", "summary"}, {"data-x", "1"}}
+	if got := p.Attrs(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if value, ok := p.Attr("data-x"); !ok || value != "1" {
+		t.Errorf("Attr(data-x) = %q, %v", value, ok)
+	}
+	if _, ok := p.Attr("class"); ok {
+		t.Error("expected class to be deleted")
+	}
+
+	p.Attrs()[0].Value = "changed"
+	if value, _ := p.Attr("id"); value != "summary" {
+		t.Error("Attrs should return a copy")
+	}
+}
+
+func TestDataKey(t *testing.T) {
+	count := NewDataKey[int]("count")
+	other := NewDataKey[int]("count")
+	labels := NewDataKey[[]string]("labels")
+
+	h := NewHeading(2)
+	if _, ok := count.Get(h); ok {
+		t.Fatal("expected no data")
+	}
+
+	count.Set(h, 3)
+	labels.Set(h, []string{"a"})
+	if n, ok := count.Get(h); !ok || n != 3 {
+		t.Errorf("count.Get = %d, %v", n, ok)
+	}
+	if _, ok := other.Get(h); ok {
+		t.Error("keys with the same name should be distinct")
+	}
+	if got, _ := labels.Get(h); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("labels.Get = %v", got)
+	}
+
+	count.Delete(h)
+	if _, ok := count.Get(h); ok {
+		t.Error("expected count to be deleted")
+	}
+	if _, ok := labels.Get(h); !ok {
+		t.Error("deleting one key should keep the others")
+	}
+}
@@ -3,9 +3,11 @@ package ast
 type Document struct{ BaseNode }
 
 func NewDocument() *Document {
-	return &Document{
+	doc := &Document{
 		BaseNode: New(NodeDocument),
 	}
+	doc.setSelf(doc)
+	return doc
 }
 
 func (d *Document) Accept(v Visitor) {
@@ -61,13 +63,40 @@ func (l *ListItem) Accept(v Visitor) {
 	v.VisitListItem(l)
 }
 
+// FencedDiv is a custom container opened by a line of three or more colons
+// followed by a name and/or attributes (::: warning {#id .class}) and closed by
+// a colon fence at least as long. Fenced divs can hold any other blocks.
+type FencedDiv struct {
+	BaseNode
+	Name       string
+	Attributes []Attribute
+	FenceLen   int
+}
+
+func NewFencedDiv(name string, fenceLen int) *FencedDiv {
+	return &FencedDiv{
+		BaseNode: New(NodeFencedDiv),
+		Name:     name,
+		FenceLen: fenceLen,
+	}
+}
+
+func (f *FencedDiv) Accept(v Visitor) {
+	v.VisitFencedDiv(f)
+}
+
+// CodeBlock is an indented or fenced code block. For fenced blocks Info holds
+// the whole info string, Language its first word, and Attributes any
+// {key=value ...} list that follows the language.
 type CodeBlock struct {
 	BaseNode
-    Literal   string
-	Language  string
-	IsFenced  bool
-	FenceChar byte
-    FenceLen  int
+    Literal    string
+	Language   string
+	Info       string
+	Attributes []Attribute
+	IsFenced   bool
+	FenceChar  byte
+    FenceLen   int
 }
 
 func NewCodeBlock(isFenced bool) *CodeBlock {
@@ -112,6 +141,7 @@ func (t *ThematicBreak) Accept(v Visitor) {
 type Heading struct {
 	BaseNode
 	Level int
+	ID    string
 }
 
 func NewHeading(level int) *Heading {
@@ -136,3 +166,37 @@ func NewParagraph() *Paragraph {
 func (p *Paragraph) Accept(v Visitor) {
 	v.VisitParagraph(p)
 }
+
+// TOC is a placeholder block, written as [TOC] or <!-- toc -->, that renderers
+// replace with an outline of the document's headings.
+type TOC struct{ BaseNode }
+
+func NewTOC() *TOC {
+	return &TOC{
+		BaseNode: New(NodeTOC),
+	}
+}
+
+func (t *TOC) Accept(v Visitor) {
+	v.VisitTOC(t)
+}
+
+// AbbreviationDefinition is a *[label]: title line. Definitions apply to the
+// whole document and are removed from the tree once inlines are parsed.
+type AbbreviationDefinition struct {
+	BaseNode
+	Label string
+	Title string
+}
+
+func NewAbbreviationDefinition(label, title string) *AbbreviationDefinition {
+	return &AbbreviationDefinition{
+		BaseNode: New(NodeAbbreviationDefinition),
+		Label:    label,
+		Title:    title,
+	}
+}
+
+func (a *AbbreviationDefinition) Accept(v Visitor) {
+	v.VisitAbbreviationDefinition(a)
+}
@@ -0,0 +1,213 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder assembles a document block by block, for generating Markdown from
+// code:
+//
+//	doc, err := ast.Build().
+//		Heading(2, "Changes").
+//		List("Faster parsing", ast.Build().Paragraph("New ", ast.NewStrong(ast.Text("fmt")), " command")).
+//		Paragraph("Thanks to ", ast.NewLink("https://example.com/team", "", ast.Text("everyone")), ".").
+//		Document()
+//
+// Inline content is given as strings, which become text, and inline nodes.
+// Blocks and inlines must be nested as Validate requires: inline content
+// holds only inline nodes and no link holds another, lists hold only items,
+// and nodes are not already in a tree. After the first mistake the builder
+// ignores further calls and Document returns the error.
+type Builder struct {
+	doc *Document
+	err error
+}
+
+// Build returns a Builder for an empty document.
+func Build() *Builder {
+	return &Builder{doc: NewDocument()}
+}
+
+// Text returns a text node, for inline content that mixes text and nodes.
+func Text(literal string) *Content {
+	return NewContent(literal)
+}
+
+// Document returns the document built so far, or the first error.
+func (b *Builder) Document() (*Document, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.doc, nil
+}
+
+// Heading adds a heading of level 1 to 6.
+func (b *Builder) Heading(level int, content ...any) *Builder {
+	if level < 1 || level > 6 {
+		return b.fail(fmt.Errorf("ast: heading level %d is not between 1 and 6", level))
+	}
+	heading := NewHeading(level)
+	return b.add(heading, addInlines(heading, content))
+}
+
+// Paragraph adds a paragraph.
+func (b *Builder) Paragraph(content ...any) *Builder {
+	paragraph := NewParagraph()
+	return b.add(paragraph, addInlines(paragraph, content))
+}
+
+// List adds a bullet list. Each item is inline content, a string or an
+// inline node, that becomes the item's paragraph, a Builder holding the
+// item's blocks, or a ListItem.
+func (b *Builder) List(items ...any) *Builder {
+	list := NewList(false)
+	list.Delimiter = '-'
+	return b.add(list, addItems(list, items))
+}
+
+// OrderedList adds a list numbered from start, with items as for List.
+func (b *Builder) OrderedList(start int, items ...any) *Builder {
+	list := NewList(true)
+	list.StartNum = start
+	list.Delimiter = '.'
+	return b.add(list, addItems(list, items))
+}
+
+// Quote adds a block quote holding the blocks of content.
+func (b *Builder) Quote(content *Builder) *Builder {
+	quote := NewBlockQuote()
+	return b.add(quote, moveBlocks(quote, content))
+}
+
+// CodeBlock adds a fenced code block, with language naming the language of
+// code or left empty.
+func (b *Builder) CodeBlock(language, code string) *Builder {
+	block := NewCodeBlock(true)
+	block.Language, block.Info = language, language
+	block.FenceChar, block.FenceLen = '`', 3
+	for _, line := range strings.Split(strings.TrimSuffix(code, "\n"), "\n") {
+		block.AddChild(NewContent(line))
+	}
+	return b.add(block, nil)
+}
+
+// ThematicBreak adds a thematic break.
+func (b *Builder) ThematicBreak() *Builder {
+	return b.add(NewThematicBreak(), nil)
+}
+
+// Block adds a block built some other way, such as an extension block.
+func (b *Builder) Block(block Node) *Builder {
+	if err := checkDetached(block); err != nil {
+		return b.fail(err)
+	}
+	if !block.Type().IsBlock() {
+		return b.fail(fmt.Errorf("ast: %s is not a block", block.Type()))
+	}
+	return b.add(block, nil)
+}
+
+// add adds block to the document unless err or Validate reports a problem.
+func (b *Builder) add(block Node, err error) *Builder {
+	if err == nil {
+		err = validateChild(b.doc, block)
+	}
+	if err != nil {
+		return b.fail(err)
+	}
+	if b.err == nil {
+		b.doc.AddChild(block)
+	}
+	return b
+}
+
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// addInlines adds content to parent as inline children.
+func addInlines(parent Node, content []any) error {
+	for _, item := range content {
+		switch item := item.(type) {
+		case string:
+			parent.AddChild(NewContent(item))
+		case Node:
+			if err := checkDetached(item); err != nil {
+				return err
+			}
+			if !item.Type().IsInline() {
+				return fmt.Errorf("ast: %s is not inline content", item.Type())
+			}
+			parent.AddChild(item)
+		default:
+			return fmt.Errorf("ast: %T is not inline content", item)
+		}
+	}
+	return nil
+}
+
+// addItems adds items, as taken by Builder.List, to list.
+func addItems(list *List, items []any) error {
+	for _, item := range items {
+		switch item := item.(type) {
+		case *ListItem:
+			if err := checkDetached(item); err != nil {
+				return err
+			}
+			list.AddChild(item)
+		case *Builder:
+			listItem := NewListItem(0)
+			if err := moveBlocks(listItem, item); err != nil {
+				return err
+			}
+			list.AddChild(listItem)
+		default:
+			paragraph := NewParagraph()
+			if err := addInlines(paragraph, []any{item}); err != nil {
+				return err
+			}
+			listItem := NewListItem(0)
+			listItem.AddChild(paragraph)
+			list.AddChild(listItem)
+		}
+	}
+
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		if item.FirstChild() != item.LastChild() {
+			list.IsTight = false
+		}
+	}
+	return nil
+}
+
+// moveBlocks moves the blocks of content into parent.
+func moveBlocks(parent Node, content *Builder) error {
+	doc, err := content.Document()
+	if err != nil {
+		return err
+	}
+	for _, block := range doc.Children() {
+		doc.RemoveChild(block)
+		parent.AddChild(block)
+	}
+	return nil
+}
+
+func checkDetached(node Node) error {
+	if node.Parent() != nil {
+		return fmt.Errorf("ast: %s is already in a tree", node.Type())
+	}
+	return nil
+}
+
+// addChildren adds children to parent, which is not in a tree yet.
+func addChildren(parent Node, children []Node) {
+	parent.setSelf(parent)
+	for _, child := range children {
+		parent.AddChild(child)
+	}
+}
@@ -0,0 +1,86 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	doc, err := Build().
+		Heading(1, "Title").
+		OrderedList(3, "one", Build().Paragraph("two").Paragraph("more")).
+		Quote(Build().Paragraph("quoted")).
+		CodeBlock("go", "x := 1\ny := 2\n").
+		ThematicBreak().
+		Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for node := range All(doc) {
+		types = append(types, node.Type().String())
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			if child.Parent() != node {
+				t.Errorf("%s has the wrong parent", child.Type())
+			}
+		}
+	}
+	expected := "document heading text list listitem paragraph text listitem paragraph text paragraph text " +
+		"blockquote paragraph text codeblock text text thematicbreak"
+	if got := strings.Join(types, " "); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	list := doc.FirstChild().NextSibling().(*List)
+	if !list.IsOrdered || list.StartNum != 3 || list.IsTight {
+		t.Errorf("unexpected list %+v", list)
+	}
+	if code := doc.LastChild().PrevSibling().(*CodeBlock); code.Language != "go" || len(code.Children()) != 2 {
+		t.Errorf("unexpected code block %+v", code)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	attached := NewContent("x")
+	NewParagraph().AddChild(attached)
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		err     string
+	}{
+		{"heading level", Build().Heading(7, "x"), "heading level 7"},
+		{"block as inline", Build().Paragraph(NewParagraph()), "paragraph is not inline content"},
+		{"nested link", Build().Paragraph(NewLink("a", "", NewEmphasis(NewLink("b", "")))), "link inside a link"},
+		{"bad content", Build().Paragraph(42), "int is not inline content"},
+		{"attached", Build().Paragraph(attached), "text is already in a tree"},
+		{"item outside list", Build().Block(blockQuoteWith(NewListItem(0))), "list item outside a list"},
+		{"list child", Build().List(Build().Paragraph("a")).Block(listWith(NewParagraph())), "paragraph inside a list"},
+		{"nested error", Build().Quote(Build().Heading(0)), "heading level 0"},
+		{"first error", Build().Heading(0).Paragraph(42), "heading level 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := tt.builder.Document()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+			if doc != nil {
+				t.Errorf("expected no document")
+			}
+		})
+	}
+}
+
+func blockQuoteWith(child Node) Node {
+	quote := NewBlockQuote()
+	quote.AddChild(child)
+	return quote
+}
+
+func listWith(child Node) Node {
+	list := NewList(false)
+	list.AddChild(child)
+	return list
+}
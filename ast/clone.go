@@ -0,0 +1,132 @@
+package ast
+
+import (
+	"hash"
+	"hash/fnv"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// Clone returns a deep copy of node and the nodes below it, detached from any
+// parent or siblings. Fields, attributes, positions and extension data are
+// copied, including nodes of registered types. Slices and maps held in fields
+// are copied too, but not the values inside them, except for slices of nodes
+// such as the sides of a Conflict, whose nodes are cloned.
+func Clone(node Node) Node {
+	original := reflect.ValueOf(node).Elem()
+	copied := reflect.New(original.Type())
+	copied.Elem().Set(original)
+	copyContainers(copied.Elem())
+	clone := copied.Interface().(Node)
+
+	base := clone.baseNode()
+	base.parent, base.prevSibling, base.nextSibling = nil, nil, nil
+	base.firstChild, base.lastChild = nil, nil
+	base.self = clone
+	base.attrs = slices.Clone(base.attrs)
+	base.data = maps.Clone(base.data)
+
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		clone.AddChild(Clone(child))
+	}
+	return clone
+}
+
+// copyContainers gives the exported slice and map fields of the node struct
+// v their own copies.
+func copyContainers(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Slice:
+			if nodes, ok := field.Interface().([]Node); ok && nodes != nil {
+				clones := make([]Node, len(nodes))
+				for i, node := range nodes {
+					clones[i] = Clone(node)
+				}
+				field.Set(reflect.ValueOf(clones))
+			} else if !field.IsNil() {
+				field.Set(reflect.AppendSlice(reflect.MakeSlice(field.Type(), 0, field.Len()), field))
+			}
+		case reflect.Map:
+			if !field.IsNil() {
+				copied := reflect.MakeMapWithSize(field.Type(), field.Len())
+				for iter := field.MapRange(); iter.Next(); {
+					copied.SetMapIndex(iter.Key(), iter.Value())
+				}
+				field.Set(copied)
+			}
+		}
+	}
+}
+
+// Equal reports whether the trees rooted at a and b have the same structure:
+// the same node types, fields and attributes, and equal children in the same
+// order. Positions and extension data are ignored.
+func Equal(a, b Node) bool {
+	if a.Type() != b.Type() || fingerprint(a) != fingerprint(b) || !equalNodeFields(a, b) {
+		return false
+	}
+	childA, childB := a.FirstChild(), b.FirstChild()
+	for ; childA != nil && childB != nil; childA, childB = childA.NextSibling(), childB.NextSibling() {
+		if !Equal(childA, childB) {
+			return false
+		}
+	}
+	return childA == nil && childB == nil
+}
+
+// Hash returns a hash of the tree rooted at node that depends only on what
+// Equal compares, so equal trees hash the same in any process. It suits
+// caching work done on unchanged nodes, such as rendering a block.
+func Hash(node Node) uint64 {
+	h := fnv.New64a()
+	writeHash(h, node)
+	return h.Sum64()
+}
+
+// equalNodeFields reports whether the fields of a and b that hold nodes,
+// which fingerprint leaves out, hold equal trees. a and b have the same type.
+func equalNodeFields(a, b Node) bool {
+	fieldsB := fieldsOf(b)
+	for name, field := range fieldsOf(a) {
+		nodesA, ok := field.(*nodeList)
+		if !ok {
+			continue
+		}
+		nodesB := fieldsB[name].(*nodeList)
+		if len(*nodesA) != len(*nodesB) {
+			return false
+		}
+		for i := range *nodesA {
+			if !Equal((*nodesA)[i], (*nodesB)[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func writeHash(h hash.Hash64, node Node) {
+	h.Write([]byte(node.Type().String()))
+	h.Write([]byte(fingerprint(node)))
+	fields := fieldsOf(node)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if nodes, ok := fields[name].(*nodeList); ok {
+			h.Write([]byte(name + "["))
+			for _, field := range *nodes {
+				writeHash(h, field)
+			}
+			h.Write([]byte("]"))
+		}
+	}
+	h.Write([]byte("("))
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		writeHash(h, child)
+	}
+	h.Write([]byte(")"))
+}
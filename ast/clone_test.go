@@ -0,0 +1,144 @@
+package ast
+
+import "testing"
+
+// cloneTestDocument builds a document with a heading, a paragraph holding a
+// link and a mention, and a code block with attributes.
+func cloneTestDocument() *Document {
+	doc := walkTestDocument()
+	doc.SetPos(Position{Start: Point{1, 1, 0}, End: Point{5, 1, 30}})
+	doc.FirstChild().SetAttr("class", "lead")
+
+	link := NewLink("https://example.com", "")
+	link.AddChild(NewContent("site"))
+	paragraph := NewParagraph()
+	paragraph.AddChild(link)
+	paragraph.AddChild(&mention{BaseNode: New(nodeMention), User: "rybkr"})
+	doc.AddChild(paragraph)
+
+	code := NewCodeBlock(true)
+	code.Language = "go"
+	code.Attributes = []Attribute{{Key: "linenos", Value: "true"}}
+	code.AddChild(NewContent("x := 1"))
+	doc.AddChild(code)
+	return doc
+}
+
+func TestClone(t *testing.T) {
+	doc := cloneTestDocument()
+	key := NewDataKey[int]("count")
+	key.Set(doc.FirstChild(), 3)
+
+	clone, ok := Clone(doc).(*Document)
+	if !ok {
+		t.Fatalf("expected a *Document, got %T", clone)
+	}
+	if !Equal(doc, clone) || clone.Pos() != doc.Pos() {
+		t.Fatalf("expected an equal copy")
+	}
+	if n, ok := key.Get(clone.FirstChild()); !ok || n != 3 {
+		t.Errorf("expected extension data to be copied, got %d", n)
+	}
+
+	for node := range All(clone) {
+		for original := range All(doc) {
+			if node == original {
+				t.Fatalf("%s shared with the original", node.Type())
+			}
+		}
+		for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+			if child.Parent() != node {
+				t.Errorf("%s has the wrong parent", child.Type())
+			}
+		}
+	}
+
+	heading := clone.FirstChild()
+	heading.SetAttr("class", "other")
+	clone.LastChild().(*CodeBlock).Attributes[0].Value = "false"
+	clone.FirstChild().NextSibling().NextSibling().LastChild().(*mention).User = "someone"
+	if class, _ := doc.FirstChild().Attr("class"); class != "lead" {
+		t.Errorf("expected the original attributes to be kept, got %q", class)
+	}
+	if value := doc.LastChild().(*CodeBlock).Attributes[0].Value; value != "true" {
+		t.Errorf("expected the original code block attributes to be kept, got %q", value)
+	}
+	if Equal(doc, clone) {
+		t.Errorf("expected changed copy to differ")
+	}
+
+	paragraph := Clone(doc.LastChild().PrevSibling())
+	if paragraph.Parent() != nil || paragraph.PrevSibling() != nil || paragraph.NextSibling() != nil {
+		t.Errorf("expected a detached copy")
+	}
+}
+
+func TestEqualAndHash(t *testing.T) {
+	a, b := cloneTestDocument(), cloneTestDocument()
+	b.SetPos(Position{})
+	b.FirstChild().SetPos(Position{Start: Point{2, 1, 3}, End: Point{2, 5, 7}})
+	if !Equal(a, b) || Hash(a) != Hash(b) {
+		t.Errorf("expected trees differing in positions to be equal")
+	}
+
+	changes := []func(doc *Document){
+		func(doc *Document) { doc.FirstChild().(*Heading).Level = 2 },
+		func(doc *Document) { doc.FirstChild().DeleteAttr("class") },
+		func(doc *Document) { doc.LastChild().(*CodeBlock).Language = "rust" },
+		func(doc *Document) { doc.RemoveChild(doc.LastChild()) },
+		func(doc *Document) { doc.LastChild().AddChild(NewContent("y := 2")) },
+		func(doc *Document) { doc.LastChild().PrevSibling().LastChild().(*mention).User = "someone" },
+	}
+	for i, change := range changes {
+		b := cloneTestDocument()
+		change(b)
+		if Equal(a, b) || Hash(a) == Hash(b) {
+			t.Errorf("change %d: expected trees to differ", i)
+		}
+	}
+
+	// Moving a child to a parent's sibling keeps the nodes but not the tree.
+	c := cloneTestDocument()
+	text := c.FirstChild().FirstChild()
+	c.FirstChild().RemoveChild(text)
+	c.FirstChild().NextSibling().AddChild(text)
+	if Hash(a) == Hash(c) {
+		t.Errorf("expected the structure to be hashed")
+	}
+}
+
+func TestConflictSides(t *testing.T) {
+	paragraph := func(text string) Node {
+		p := NewParagraph()
+		p.AddChild(NewContent(text))
+		return p
+	}
+	one := NewConflict([]Node{paragraph("one")}, nil)
+	two := NewConflict([]Node{paragraph("two")}, nil)
+	if Equal(one, two) || Hash(one) == Hash(two) {
+		t.Errorf("expected conflicts with different sides to differ")
+	}
+	if same := NewConflict([]Node{paragraph("one")}, nil); !Equal(one, same) || Hash(one) != Hash(same) {
+		t.Errorf("expected conflicts with equal sides to be equal")
+	}
+
+	clone := Clone(one).(*Conflict)
+	if !Equal(one, clone) {
+		t.Fatalf("expected an equal copy")
+	}
+	if clone.Ours[0] == one.Ours[0] {
+		t.Errorf("expected the sides to be copied")
+	}
+
+	data, err := MarshalJSON(one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(one, decoded) {
+		t.Errorf("expected the sides to survive encoding, got %s", data)
+	}
+}
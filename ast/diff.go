@@ -0,0 +1,430 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// EditKind is the kind of difference an Edit reports.
+type EditKind int
+
+const (
+	// Inserted nodes are only in the new tree.
+	Inserted EditKind = iota
+	// Deleted nodes are only in the old tree.
+	Deleted
+	// Changed nodes are in both trees but differ. Containers are changed when
+	// their own fields differ; their children are compared separately.
+	Changed
+	// Moved nodes are in both trees, unchanged, but in a different place.
+	Moved
+)
+
+func (k EditKind) String() string {
+	switch k {
+	case Inserted:
+		return "inserted"
+	case Deleted:
+		return "deleted"
+	case Changed:
+		return "changed"
+	case Moved:
+		return "moved"
+	}
+	return fmt.Sprintf("EditKind(%d)", int(k))
+}
+
+// Edit is one difference between two trees. Old is nil for insertions and
+// New is nil for deletions.
+type Edit struct {
+	Kind EditKind
+	Old  Node
+	New  Node
+
+	// Parent and Index place deleted and moved nodes in the new tree: the old
+	// node was among the children of the node Parent took the place of, just
+	// before the child now at Index, or at the end if Index is the number of
+	// children. Parent is nil when the roots themselves differ.
+	Parent Node
+	Index  int
+}
+
+// Diff compares the trees rooted at a and b and returns the edits that turn
+// a into b, in the order of b with deletions where the deleted nodes were.
+//
+// Container blocks are matched child by child, so an edit deep in a list is
+// reported for the paragraph that changed rather than for the whole list.
+// Leaf blocks such as paragraphs and headings are compared as a whole.
+// Differences in formatting alone are ignored: positions, fence and bullet
+// characters, list tightness, generated heading IDs, and how text is split
+// into lines. A block deleted in one place and inserted unchanged in another
+// is reported once as Moved, followed by any edits between the two.
+func Diff(a, b Node) []Edit {
+	d := &differ{keys: make(map[Node]uint64)}
+	d.diff(a, b)
+	return d.findMoves()
+}
+
+type differ struct {
+	edits []Edit
+	keys  map[Node]uint64
+}
+
+func (d *differ) diff(a, b Node) {
+	if a.Type() != b.Type() {
+		d.edits = append(d.edits, Edit{Kind: Deleted, Old: a}, Edit{Kind: Inserted, New: b})
+		return
+	}
+	if d.key(a) == d.key(b) {
+		return
+	}
+	if !a.Type().IsContainer() {
+		d.edits = append(d.edits, Edit{Kind: Changed, Old: a, New: b})
+		return
+	}
+	if semanticFields(a) != semanticFields(b) {
+		d.edits = append(d.edits, Edit{Kind: Changed, Old: a, New: b})
+	}
+	d.diffChildren(a.Children(), b)
+}
+
+// diffChildren compares old with the children of parent.
+func (d *differ) diffChildren(old []Node, parent Node) {
+	new := parent.Children()
+	i, j := 0, 0
+	for _, pair := range append(d.match(old, new), [2]int{len(old), len(new)}) {
+		for ; i < pair[0]; i++ {
+			d.edits = append(d.edits, Edit{Kind: Deleted, Old: old[i], Parent: parent, Index: j})
+		}
+		for ; j < pair[1]; j++ {
+			d.edits = append(d.edits, Edit{Kind: Inserted, New: new[j]})
+		}
+		if i < len(old) && j < len(new) {
+			d.diff(old[i], new[j])
+		}
+		i, j = i+1, j+1
+	}
+}
+
+// match pairs the nodes of old with those of new. Identical nodes are
+// matched first, then, in each gap between those, nodes that are similar.
+func (d *differ) match(old, new []Node) [][2]int {
+	exact := lcs(len(old), len(new), func(i, j int) float64 {
+		if d.key(old[i]) == d.key(new[j]) {
+			return 1
+		}
+		return 0
+	})
+
+	var pairs [][2]int
+	i, j := 0, 0
+	for _, pair := range append(exact, [2]int{len(old), len(new)}) {
+		gapOld, gapNew := old[i:pair[0]], new[j:pair[1]]
+		similar := lcs(len(gapOld), len(gapNew), func(k, l int) float64 {
+			return d.similar(gapOld[k], gapNew[l])
+		})
+		for _, p := range similar {
+			pairs = append(pairs, [2]int{i + p[0], j + p[1]})
+		}
+		if pair[0] < len(old) {
+			pairs = append(pairs, pair)
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+	return pairs
+}
+
+// similar scores how alike two nodes are, or returns 0 if they should not be
+// paired. Containers of the same type always pair; other nodes need half
+// their words in common.
+func (d *differ) similar(a, b Node) float64 {
+	if a.Type() != b.Type() {
+		return 0
+	}
+	if a.Type().IsContainer() {
+		return 0.5
+	}
+	if s := similarity(textOf(a), textOf(b)); s >= 0.5 {
+		return s
+	}
+	return 0
+}
+
+// findMoves turns a deletion and an insertion of the same node into one
+// move, reported where the node was inserted. Identical nodes are paired
+// first, then nodes of the same type that are similar enough; the edits
+// between those follow the move.
+func (d *differ) findMoves() []Edit {
+	var deleted []int
+	for i, edit := range d.edits {
+		if edit.Kind == Deleted {
+			deleted = append(deleted, i)
+		}
+	}
+
+	moved := make(map[int]int)
+	pair := func(match func(old, new Node) float64) {
+		for i, edit := range d.edits {
+			if edit.Kind != Inserted || moved[i] != 0 {
+				continue
+			}
+			best, bestScore := -1, 0.0
+			for _, j := range deleted {
+				if moved[j] != 0 {
+					continue
+				}
+				if score := match(d.edits[j].Old, edit.New); score > bestScore {
+					best, bestScore = j, score
+				}
+			}
+			if best >= 0 {
+				moved[i], moved[best] = best+1, i+1
+			}
+		}
+	}
+	pair(func(old, new Node) float64 {
+		if d.key(old) == d.key(new) {
+			return 1
+		}
+		return 0
+	})
+	pair(func(old, new Node) float64 {
+		if old.Type() != new.Type() {
+			return 0
+		}
+		if s := similarity(textOf(old), textOf(new)); s >= 0.5 {
+			return s
+		}
+		return 0
+	})
+
+	var edits []Edit
+	for i, edit := range d.edits {
+		switch {
+		case moved[i] == 0:
+			edits = append(edits, edit)
+		case edit.Kind == Inserted:
+			from := d.edits[moved[i]-1]
+			edits = append(edits, Edit{Kind: Moved, Old: from.Old, New: edit.New, Parent: from.Parent, Index: from.Index})
+			inner := &differ{keys: d.keys}
+			inner.diff(from.Old, edit.New)
+			edits = append(edits, inner.edits...)
+		}
+	}
+	return edits
+}
+
+// lcs returns the index pairs of a highest scoring alignment of two
+// sequences, where score gives the value of pairing i with j, or 0 if they
+// cannot be paired.
+func lcs(n, m int, score func(i, j int) float64) [][2]int {
+	table := make([][]float64, n+1)
+	for i := range table {
+		table[i] = make([]float64, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			best := max(table[i+1][j], table[i][j+1])
+			if s := score(i, j); s > 0 {
+				best = max(best, table[i+1][j+1]+s)
+			}
+			table[i][j] = best
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch s := score(i, j); {
+		case s > 0 && table[i][j] == table[i+1][j+1]+s:
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// similarity is the share of words two texts have in common, in order,
+// ignoring punctuation.
+func similarity(a, b string) float64 {
+	wordsA, wordsB := words(a), words(b)
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+	common := len(lcs(len(wordsA), len(wordsB), func(i, j int) float64 {
+		if wordsA[i] == wordsB[j] {
+			return 1
+		}
+		return 0
+	}))
+	return 2 * float64(common) / float64(len(wordsA)+len(wordsB))
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// key returns a hash of node that is the same for trees differing only in
+// formatting.
+func (d *differ) key(node Node) uint64 {
+	if key, ok := d.keys[node]; ok {
+		return key
+	}
+	key := d.hash(node)
+	d.keys[node] = key
+	return key
+}
+
+// hash computes the key of node, using the cached keys of its children.
+func (d *differ) hash(node Node) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(node.Type().String()))
+	h.Write([]byte(semanticFields(node)))
+	if _, ok := node.(*CodeBlock); ok {
+		// Code keeps its lines and spacing.
+		for _, child := range node.Children() {
+			if content, ok := child.(*Content); ok {
+				h.Write([]byte(content.Literal + "\n"))
+			}
+		}
+	}
+	for _, child := range normalizedChildren(node) {
+		var key uint64
+		if _, ok := child.(*Content); ok {
+			// Text nodes here are merged runs made for this call alone,
+			// so caching them would only grow the map.
+			key = d.hash(child)
+		} else {
+			key = d.key(child)
+		}
+		var buf [8]byte
+		for i := range buf {
+			buf[i] = byte(key >> (8 * i))
+		}
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// formattingFields are fields that only record how a node was written.
+var formattingFields = map[NodeType][]string{
+	NodeList:      {"delimiter", "tight"},
+	NodeListItem:  {"indent"},
+	NodeFencedDiv: {"fenceLength"},
+	NodeCodeBlock: {"fenced", "fenceChar", "fenceLength"},
+	NodeHeading:   {"id"},
+}
+
+// semanticFields encodes the fields and attributes of node that affect its
+// meaning.
+func semanticFields(node Node) string {
+	fields := fieldsOf(node)
+	for _, name := range formattingFields[node.Type()] {
+		delete(fields, name)
+	}
+	if content, ok := node.(*Content); ok {
+		fields["literal"] = collapseSpace(content.Literal)
+	}
+	for name, field := range fields {
+		if nodes, ok := field.(*nodeList); ok {
+			hashes := make([]uint64, len(*nodes))
+			for i, node := range *nodes {
+				hashes[i] = Hash(node)
+			}
+			fields[name] = hashes
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Fields     map[string]any `json:"fields"`
+		Attributes []Attribute    `json:"attributes"`
+	}{fields, node.Attrs()})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// collapseSpace replaces each run of white space in s with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// normalizedChildren returns the children of node with each run of text and
+// soft breaks merged into one text node, so that text split differently
+// across lines or nodes compares equal.
+func normalizedChildren(node Node) []Node {
+	if _, ok := node.(*CodeBlock); ok {
+		return nil
+	}
+
+	var children []Node
+	var run strings.Builder
+	inRun := false
+	flush := func() {
+		if inRun {
+			children = append(children, NewContent(run.String()))
+			run.Reset()
+			inRun = false
+		}
+	}
+
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch c := child.(type) {
+		case *Content:
+			run.WriteString(c.Literal)
+			inRun = true
+		case *SoftBreak:
+			run.WriteString(" ")
+			inRun = true
+		default:
+			flush()
+			children = append(children, child)
+		}
+	}
+	flush()
+	return children
+}
+
+// textOf returns the text of node and its descendants.
+func textOf(node Node) string {
+	var b strings.Builder
+	for n := range All(node) {
+		switch n := n.(type) {
+		case *Content:
+			b.WriteString(n.Literal)
+		case *CodeSpan:
+			b.WriteString(n.Literal)
+		case *SoftBreak, *LineBreak:
+			b.WriteString(" ")
+		}
+		if n.Type().IsBlock() && n != node {
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,166 @@
+package ast
+
+import "testing"
+
+// diffTestDocument builds a document with a heading, a paragraph for each of
+// texts and a block quote holding one more paragraph.
+func diffTestDocument(heading string, texts ...string) *Document {
+	doc := NewDocument()
+	h := NewHeading(1)
+	h.AddChild(NewContent(heading))
+	doc.AddChild(h)
+	for _, text := range texts {
+		doc.AddChild(diffTestParagraph(text))
+	}
+	quote := NewBlockQuote()
+	quote.AddChild(diffTestParagraph("quoted text"))
+	doc.AddChild(quote)
+	return doc
+}
+
+func diffTestParagraph(text string) *Paragraph {
+	p := NewParagraph()
+	p.AddChild(NewContent(text))
+	return p
+}
+
+type diffSummary struct {
+	kind EditKind
+	text string
+}
+
+func summarize(edits []Edit) []diffSummary {
+	var summary []diffSummary
+	for _, edit := range edits {
+		node := edit.New
+		if node == nil {
+			node = edit.Old
+		}
+		summary = append(summary, diffSummary{edit.Kind, textOf(node)})
+	}
+	return summary
+}
+
+func checkEdits(t *testing.T, edits []Edit, want ...diffSummary) {
+	t.Helper()
+	got := summarize(edits)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("edit %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDiffIgnoresFormatting(t *testing.T) {
+	a := diffTestDocument("Title", "one two three")
+	b := diffTestDocument("Title")
+
+	// The same paragraph split over two lines, with different positions.
+	p := NewParagraph()
+	p.AddChild(NewContent("one  two"))
+	p.AddChild(NewSoftBreak())
+	p.AddChild(NewContent("three"))
+	p.SetPos(Position{Start: Point{Line: 3, Column: 1}, End: Point{Line: 4, Column: 6}})
+	b.InsertAfter(b.FirstChild(), p)
+	b.FirstChild().(*Heading).ID = "title"
+
+	codeA, codeB := NewCodeBlock(true), NewCodeBlock(true)
+	codeA.FenceChar, codeA.FenceLen = '`', 3
+	codeB.FenceChar, codeB.FenceLen = '~', 4
+	for _, code := range []*CodeBlock{codeA, codeB} {
+		code.Language = "go"
+		code.AddChild(NewContent("x := 1"))
+	}
+	a.AddChild(codeA)
+	b.AddChild(codeB)
+
+	if edits := Diff(a, b); len(edits) != 0 {
+		t.Errorf("expected no edits, got %v", summarize(edits))
+	}
+}
+
+func TestDiffKeysOnlyTreeNodes(t *testing.T) {
+	doc := diffTestDocument("Title", "one", "two")
+	p := doc.FirstChild().NextSibling()
+	p.AddChild(NewSoftBreak())
+	p.AddChild(NewContent("more"))
+
+	d := &differ{keys: make(map[Node]uint64)}
+	d.key(doc)
+	d.key(doc)
+	inTree := make(map[Node]bool)
+	for node := range All(doc) {
+		inTree[node] = true
+	}
+	for node := range d.keys {
+		if !inTree[node] {
+			t.Errorf("cached key for %s %q outside the tree", node.Type(), textOf(node))
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := diffTestDocument("Title", "first paragraph here", "second paragraph here")
+
+	t.Run("changed", func(t *testing.T) {
+		b := diffTestDocument("Title", "first paragraph here", "second paragraph changed here")
+		edits := Diff(a, b)
+		checkEdits(t, edits, diffSummary{Changed, "second paragraph changed here"})
+		if textOf(edits[0].Old) != "second paragraph here" {
+			t.Errorf("expected the old paragraph, got %q", textOf(edits[0].Old))
+		}
+	})
+
+	t.Run("inserted and deleted", func(t *testing.T) {
+		b := diffTestDocument("Title", "first paragraph here", "something else entirely")
+		edits := Diff(a, b)
+		checkEdits(t, edits,
+			diffSummary{Deleted, "second paragraph here"},
+			diffSummary{Inserted, "something else entirely"})
+		if edits[0].Parent != b || edits[0].Index != 2 {
+			t.Errorf("expected the deletion before child 2 of the new document, got %d", edits[0].Index)
+		}
+	})
+
+	t.Run("moved", func(t *testing.T) {
+		b := diffTestDocument("Title", "second paragraph here", "first paragraph here")
+		edits := Diff(a, b)
+		checkEdits(t, edits, diffSummary{Moved, "first paragraph here"})
+		if edits[0].Old == nil || edits[0].Old == edits[0].New {
+			t.Errorf("expected the old node of a move")
+		}
+	})
+
+	t.Run("moved and changed", func(t *testing.T) {
+		b := diffTestDocument("Title", "second paragraph here", "other text", "first paragraph changed here")
+		checkEdits(t, Diff(a, b),
+			diffSummary{Inserted, "other text"},
+			diffSummary{Moved, "first paragraph changed here"},
+			diffSummary{Changed, "first paragraph changed here"})
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		b := diffTestDocument("Title", "first paragraph here", "second paragraph here")
+		b.LastChild().FirstChild().FirstChild().(*Content).Literal = "quoted text too"
+		checkEdits(t, Diff(a, b), diffSummary{Changed, "quoted text too"})
+	})
+
+	t.Run("heading level", func(t *testing.T) {
+		b := diffTestDocument("Title", "first paragraph here", "second paragraph here")
+		b.FirstChild().(*Heading).Level = 2
+		checkEdits(t, Diff(a, b), diffSummary{Changed, "Title"})
+	})
+
+	t.Run("inline", func(t *testing.T) {
+		b := diffTestDocument("Title", "first paragraph here", "second paragraph here")
+		em := NewEmphasis()
+		em.AddChild(NewContent("here"))
+		p := b.FirstChild().NextSibling()
+		p.FirstChild().(*Content).Literal = "first paragraph "
+		p.AddChild(em)
+		checkEdits(t, Diff(a, b), diffSummary{Changed, "first paragraph here"})
+	})
+}
@@ -0,0 +1,43 @@
+package ast_test
+
+import (
+	"fmt"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/renderer"
+)
+
+func ExampleBuild() {
+	doc, err := ast.Build().
+		Heading(2, "Changes").
+		List(
+			"Faster parsing",
+			ast.Build().Paragraph("New ", ast.NewStrong(ast.Text("fmt")), " command"),
+		).
+		Paragraph("Thanks to ", ast.NewLink("https://example.com/team", "", ast.Text("everyone")), ".").
+		Document()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Print(renderer.RenderMarkdown(doc))
+	fmt.Println()
+	fmt.Print(renderer.RenderHTML(doc))
+	// Output:
+	// ## Changes
+	//
+	// - Faster parsing
+	// - New **fmt** command
+	//
+	// Thanks to [everyone](https://example.com/team).
+	//
+	// <h2>Changes</h2>
+	// <ul>
+	// <li><p>Faster parsing</p>
+	// </li>
+	// <li><p>New <strong>fmt</strong> command</p>
+	// </li>
+	// </ul>
+	// <p>Thanks to <a href="https://example.com/team">everyone</a>.</p>
+}
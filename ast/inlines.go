@@ -34,10 +34,14 @@ func (h *HTMLSpan) Accept(v Visitor) {
 
 type Emphasis struct{ BaseNode }
 
-func NewEmphasis() *Emphasis {
-	return &Emphasis{
+// NewEmphasis returns emphasis around children, which can also be added
+// later.
+func NewEmphasis(children ...Node) *Emphasis {
+	e := &Emphasis{
 		BaseNode: New(NodeEmphasis),
 	}
+	addChildren(e, children)
+	return e
 }
 
 func (e *Emphasis) Accept(v Visitor) {
@@ -46,28 +50,53 @@ func (e *Emphasis) Accept(v Visitor) {
 
 type Strong struct{ BaseNode }
 
-func NewStrong() *Strong {
-	return &Strong{
+// NewStrong returns strong emphasis around children, which can also be added
+// later.
+func NewStrong(children ...Node) *Strong {
+	s := &Strong{
 		BaseNode: New(NodeStrong),
 	}
+	addChildren(s, children)
+	return s
 }
 
 func (s *Strong) Accept(v Visitor) {
     v.VisitStrong(s)
 }
 
+// Strikethrough is GFM's ~~deleted~~ text.
+type Strikethrough struct{ BaseNode }
+
+// NewStrikethrough returns struck-through text around children, which can
+// also be added later.
+func NewStrikethrough(children ...Node) *Strikethrough {
+	s := &Strikethrough{
+		BaseNode: New(NodeStrikethrough),
+	}
+	addChildren(s, children)
+	return s
+}
+
+func (s *Strikethrough) Accept(v Visitor) {
+    v.VisitStrikethrough(s)
+}
+
 type Link struct {
 	BaseNode
 	Destination string
 	Title       string
 }
 
-func NewLink(destination, title string) *Link {
-	return &Link{
+// NewLink returns a link to destination with children as its text, which
+// can also be added later.
+func NewLink(destination, title string, children ...Node) *Link {
+	l := &Link{
 		BaseNode:    New(NodeLink),
 		Destination: destination,
 		Title:       title,
 	}
+	addChildren(l, children)
+	return l
 }
 
 func (l *Link) Accept(v Visitor) {
@@ -133,3 +162,21 @@ func NewContent(literal string) *Content {
 func (c *Content) Accept(v Visitor) {
     v.VisitContent(c)
 }
+
+// Abbreviation wraps a use of a defined abbreviation. Its children hold the
+// abbreviated text; Title is the expansion.
+type Abbreviation struct {
+	BaseNode
+	Title string
+}
+
+func NewAbbreviation(title string) *Abbreviation {
+	return &Abbreviation{
+		BaseNode: New(NodeAbbreviation),
+		Title:    title,
+	}
+}
+
+func (a *Abbreviation) Accept(v Visitor) {
+    v.VisitAbbreviation(a)
+}
@@ -0,0 +1,364 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MarshalJSON encodes the tree rooted at node. Every node becomes an object
+// of the form
+//
+//	{
+//	  "type": "heading",
+//	  "position": {"start": {"line": 1, "column": 1, "offset": 0}, "end": {...}},
+//	  "fields": {"id": "", "level": 2},
+//	  "attributes": [{"key": "class", "value": "lead"}],
+//	  "children": [...]
+//	}
+//
+// where type is the NodeType name and fields holds the node's own fields under
+// lower-case names. Position, attributes and children are left out when
+// empty, and fields when the node has none. Data set with SetData is not
+// encoded.
+func MarshalJSON(node Node) ([]byte, error) {
+	encoded, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a tree encoded by MarshalJSON. Nodes of a registered
+// type can only be decoded if a factory was registered for the type with
+// RegisterNodeFactory. Unknown fields are ignored.
+func UnmarshalJSON(data []byte) (Node, error) {
+	var encoded jsonNode
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	return decodeNode(&encoded)
+}
+
+// MarshalJSON encodes the document as described for the MarshalJSON function.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(d)
+}
+
+// UnmarshalJSON replaces the contents of the document with a tree encoded by
+// MarshalJSON, whose root must be a document.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	decoded, ok := node.(*Document)
+	if !ok {
+		return fmt.Errorf("ast: cannot decode %s into a document", node.Type())
+	}
+
+	*d = Document{BaseNode: New(NodeDocument)}
+	d.setSelf(d)
+	d.pos = decoded.pos
+	d.attrs = decoded.attrs
+	for _, child := range decoded.Children() {
+		decoded.RemoveChild(child)
+		d.AddChild(child)
+	}
+	return nil
+}
+
+// Fielder is implemented by extension nodes that have fields of their own.
+// Fields returns pointers to the fields keyed by their JSON name, so the same
+// map is used to encode and decode them:
+//
+//	func (k *Keyboard) Fields() map[string]any {
+//		return map[string]any{"key": &k.Key}
+//	}
+type Fielder interface {
+	Fields() map[string]any
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[NodeType]func() Node{}
+)
+
+// RegisterNodeFactory sets the function used to create empty nodes of a
+// registered type when decoding them. The returned node must already have
+// type t.
+func RegisterNodeFactory(t NodeType, factory func() Node) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[t] = factory
+}
+
+// NewNode returns an empty node of type t, using the registered factory for
+// extension types. It returns nil if t is unknown or has no factory.
+func NewNode(t NodeType) Node {
+	switch t {
+	case NodeDocument:
+		return NewDocument()
+	case NodeBlockQuote:
+		return NewBlockQuote()
+	case NodeList:
+		return NewList(false)
+	case NodeListItem:
+		return NewListItem(0)
+	case NodeFencedDiv:
+		return NewFencedDiv("", 0)
+	case NodeCodeBlock:
+		return NewCodeBlock(false)
+	case NodeHTMLBlock:
+		return NewHTMLBlock("")
+	case NodeThematicBreak:
+		return NewThematicBreak()
+	case NodeHeading:
+		return NewHeading(0)
+	case NodeParagraph:
+		return NewParagraph()
+	case NodeTOC:
+		return NewTOC()
+	case NodeAbbreviationDefinition:
+		return NewAbbreviationDefinition("", "")
+	case NodeCodeSpan:
+		return NewCodeSpan("")
+	case NodeHTMLSpan:
+		return NewHTMLSpan("")
+	case NodeEmphasis:
+		return NewEmphasis()
+	case NodeStrong:
+		return NewStrong()
+	case NodeLink:
+		return NewLink("", "")
+	case NodeImage:
+		return NewImage("", "", "")
+	case NodeSoftBreak:
+		return NewSoftBreak()
+	case NodeLineBreak:
+		return NewLineBreak()
+	case NodeContent:
+		return NewContent("")
+	case NodeAbbreviation:
+		return NewAbbreviation("")
+	case NodeStrikethrough:
+		return NewStrikethrough()
+	case NodeConflict:
+		return NewConflict(nil, nil)
+	}
+
+	factoriesMu.RLock()
+	factory := factories[t]
+	factoriesMu.RUnlock()
+	if factory == nil {
+		return nil
+	}
+	return factory()
+}
+
+// fieldsOf returns pointers to the fields of node, keyed by JSON name.
+func fieldsOf(node Node) map[string]any {
+	switch n := node.(type) {
+	case *List:
+		return map[string]any{
+			"ordered":   &n.IsOrdered,
+			"tight":     &n.IsTight,
+			"start":     &n.StartNum,
+			"delimiter": (*char)(&n.Delimiter),
+		}
+	case *ListItem:
+		return map[string]any{"indent": &n.Indent}
+	case *FencedDiv:
+		return map[string]any{
+			"name":        &n.Name,
+			"attributes":  &n.Attributes,
+			"fenceLength": &n.FenceLen,
+		}
+	case *CodeBlock:
+		return map[string]any{
+			"literal":     &n.Literal,
+			"language":    &n.Language,
+			"info":        &n.Info,
+			"attributes":  &n.Attributes,
+			"fenced":      &n.IsFenced,
+			"fenceChar":   (*char)(&n.FenceChar),
+			"fenceLength": &n.FenceLen,
+		}
+	case *HTMLBlock:
+		return map[string]any{"literal": &n.Literal}
+	case *Heading:
+		return map[string]any{"level": &n.Level, "id": &n.ID}
+	case *AbbreviationDefinition:
+		return map[string]any{"label": &n.Label, "title": &n.Title}
+	case *CodeSpan:
+		return map[string]any{"literal": &n.Literal}
+	case *HTMLSpan:
+		return map[string]any{"literal": &n.Literal}
+	case *Link:
+		return map[string]any{"destination": &n.Destination, "title": &n.Title}
+	case *Image:
+		return map[string]any{"destination": &n.Destination, "title": &n.Title, "alt": &n.AltText}
+	case *Content:
+		return map[string]any{"literal": &n.Literal}
+	case *Abbreviation:
+		return map[string]any{"title": &n.Title}
+	case Fielder:
+		return n.Fields()
+	}
+	return nil
+}
+
+// char encodes a byte field such as a list delimiter as a one-character
+// string, or "" when unset.
+type char byte
+
+func (c *char) MarshalJSON() ([]byte, error) {
+	if *c == 0 {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(string([]byte{byte(*c)}))
+}
+
+func (c *char) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch len(s) {
+	case 0:
+		*c = 0
+	case 1:
+		*c = char(s[0])
+	default:
+		return fmt.Errorf("ast: expected a single character, got %q", s)
+	}
+	return nil
+}
+
+// nodeList encodes a field holding nodes, such as the sides of a Conflict,
+// as a list of encoded trees.
+type nodeList []Node
+
+func (l *nodeList) MarshalJSON() ([]byte, error) {
+	encoded := make([]*jsonNode, len(*l))
+	for i, node := range *l {
+		var err error
+		if encoded[i], err = encodeNode(node); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+func (l *nodeList) UnmarshalJSON(data []byte) error {
+	var encoded []*jsonNode
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	*l = make(nodeList, len(encoded))
+	for i, node := range encoded {
+		var err error
+		if (*l)[i], err = decodeNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonNode struct {
+	Type       string                     `json:"type"`
+	Position   *jsonPosition              `json:"position,omitempty"`
+	Fields     map[string]json.RawMessage `json:"fields,omitempty"`
+	Attributes []Attribute                `json:"attributes,omitempty"`
+	Children   []*jsonNode                `json:"children,omitempty"`
+}
+
+type jsonPosition struct {
+	Start jsonPoint `json:"start"`
+	End   jsonPoint `json:"end"`
+}
+
+type jsonPoint struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+func encodeNode(node Node) (*jsonNode, error) {
+	encoded := &jsonNode{
+		Type:       node.Type().String(),
+		Attributes: node.Attrs(),
+	}
+	if _, ok := node.Type().kind(); !ok {
+		return nil, fmt.Errorf("ast: cannot encode unknown %s", node.Type())
+	}
+
+	if pos := node.Pos(); pos.IsValid() {
+		encoded.Position = &jsonPosition{
+			Start: jsonPoint(pos.Start),
+			End:   jsonPoint(pos.End),
+		}
+	}
+
+	if fields := fieldsOf(node); len(fields) > 0 {
+		encoded.Fields = make(map[string]json.RawMessage, len(fields))
+		for name, field := range fields {
+			value, err := json.Marshal(field)
+			if err != nil {
+				return nil, fmt.Errorf("ast: encoding %s field %q: %w", encoded.Type, name, err)
+			}
+			encoded.Fields[name] = value
+		}
+	}
+
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		encodedChild, err := encodeNode(child)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Children = append(encoded.Children, encodedChild)
+	}
+	return encoded, nil
+}
+
+func decodeNode(encoded *jsonNode) (Node, error) {
+	t, ok := NodeTypeByName(encoded.Type)
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown node type %q", encoded.Type)
+	}
+	node := NewNode(t)
+	if node == nil {
+		return nil, fmt.Errorf("ast: no factory registered for node type %q", encoded.Type)
+	}
+	// The children added below point at node itself, not its BaseNode.
+	node.setSelf(node)
+
+	if p := encoded.Position; p != nil {
+		node.SetPos(Position{Start: Point(p.Start), End: Point(p.End)})
+	}
+
+	fields := fieldsOf(node)
+	for name, value := range encoded.Fields {
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, field); err != nil {
+			return nil, fmt.Errorf("ast: decoding %s field %q: %w", encoded.Type, name, err)
+		}
+	}
+
+	for _, attr := range encoded.Attributes {
+		node.SetAttr(attr.Key, attr.Value)
+	}
+
+	for _, child := range encoded.Children {
+		decodedChild, err := decodeNode(child)
+		if err != nil {
+			return nil, err
+		}
+		node.AddChild(decodedChild)
+	}
+	node.SetOpen(false)
+	return node, nil
+}
@@ -0,0 +1,155 @@
+package ast
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var nodeMention = RegisterNodeType("mention", CategoryInline)
+
+type mention struct {
+	BaseNode
+	User string
+}
+
+func (m *mention) Accept(v Visitor) {
+	v.VisitNode(m)
+}
+
+func (m *mention) Fields() map[string]any {
+	return map[string]any{"user": &m.User}
+}
+
+func init() {
+	RegisterNodeFactory(nodeMention, func() Node {
+		return &mention{BaseNode: New(nodeMention)}
+	})
+}
+
+func TestMarshalJSON(t *testing.T) {
+	heading := NewHeading(2)
+	heading.SetPos(Position{Start: Point{1, 1, 0}, End: Point{1, 8, 7}})
+	heading.SetAttr("class", "lead")
+	heading.AddChild(NewContent("Title"))
+	doc := NewDocument()
+	doc.AddChild(heading)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"document","children":[{"type":"heading",` +
+		`"position":{"start":{"line":1,"column":1,"offset":0},"end":{"line":1,"column":8,"offset":7}},` +
+		`"fields":{"id":"","level":2},"attributes":[{"key":"class","value":"lead"}],` +
+		`"children":[{"type":"text","fields":{"literal":"Title"}}]}]}`
+	if string(data) != want {
+		t.Errorf("unexpected JSON\n got: %s\nwant: %s", data, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	list := NewList(true)
+	list.StartNum = 3
+	list.Delimiter = ')'
+	item := NewListItem(3)
+	list.AddChild(item)
+
+	code := NewCodeBlock(true)
+	code.Literal = "x := 1\n"
+	code.Language = "go"
+	code.Info = "go {linenos=true}"
+	code.Attributes = []Attribute{{"linenos", "true"}}
+	code.FenceChar = '`'
+	code.FenceLen = 3
+	item.AddChild(code)
+
+	paragraph := NewParagraph()
+	paragraph.SetAttr("id", "p1")
+	paragraph.SetAttr("data-x", "1")
+	link := NewLink("/a", "A")
+	link.AddChild(NewContent("a"))
+	paragraph.AddChild(link)
+	paragraph.AddChild(&mention{BaseNode: New(nodeMention), User: "bob"})
+	paragraph.AddChild(NewImage("i.png", "", "alt"))
+
+	doc := NewDocument()
+	doc.SetPos(Position{Start: Point{1, 1, 0}, End: Point{4, 2, 30}})
+	doc.AddChild(list)
+	doc.AddChild(paragraph)
+
+	data, err := MarshalJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Document
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := MarshalJSON(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("round trip changed the JSON\n got: %s\nwant: %s", again, data)
+	}
+
+	if decoded.Pos() != doc.Pos() {
+		t.Errorf("expected position %v, got %v", doc.Pos(), decoded.Pos())
+	}
+	gotList := decoded.FirstChild().(*List)
+	if gotList.Parent() != Node(&decoded) || gotList.Delimiter != ')' || gotList.StartNum != 3 {
+		t.Errorf("unexpected list %+v", gotList)
+	}
+	gotCode := gotList.FirstChild().FirstChild().(*CodeBlock)
+	if !reflect.DeepEqual(gotCode.Attributes, code.Attributes) || gotCode.FenceChar != '`' {
+		t.Errorf("unexpected code block %+v", gotCode)
+	}
+	gotParagraph := decoded.LastChild()
+	if !reflect.DeepEqual(gotParagraph.Attrs(), paragraph.Attrs()) {
+		t.Errorf("expected attributes %v, got %v", paragraph.Attrs(), gotParagraph.Attrs())
+	}
+	if m, ok := gotParagraph.Children()[1].(*mention); !ok || m.User != "bob" {
+		t.Errorf("expected the mention to be decoded, got %#v", gotParagraph.Children()[1])
+	}
+}
+
+func TestUnmarshalJSONParagraphParent(t *testing.T) {
+	node, err := UnmarshalJSON([]byte(`{"type":"paragraph","children":[{"type":"text","fields":{"literal":"a"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paragraph, ok := node.(*Paragraph)
+	if !ok {
+		t.Fatalf("expected a *Paragraph, got %T", node)
+	}
+	if parent := paragraph.FirstChild().Parent(); parent != Node(paragraph) {
+		t.Errorf("expected the text's parent to be the paragraph, got %T", parent)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"type":"nope"}`, `unknown node type "nope"`},
+		{`{"type":"kbd"}`, `no factory registered for node type "kbd"`},
+		{`{"type":"list","fields":{"delimiter":"))"}}`, `decoding list field "delimiter"`},
+		{`{"type":"paragraph","children":[{"type":"heading","fields":{"level":"2"}}]}`, `decoding heading field "level"`},
+	}
+	for _, tt := range tests {
+		_, err := UnmarshalJSON([]byte(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.input, tt.err, err)
+		}
+	}
+
+	var doc Document
+	if err := json.Unmarshal([]byte(`{"type":"paragraph"}`), &doc); err == nil {
+		t.Error("expected an error decoding a paragraph into a document")
+	}
+}
@@ -0,0 +1,146 @@
+package ast
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Category places a node type in the document structure.
+type Category int
+
+const (
+	// CategoryInline nodes appear inside paragraphs and headings.
+	CategoryInline Category = iota
+	// CategoryLeaf blocks cannot contain other blocks.
+	CategoryLeaf
+	// CategoryContainer blocks can contain other blocks.
+	CategoryContainer
+)
+
+// firstRegisteredType leaves room for built-in node types to be added
+// without colliding with registered ones.
+const firstRegisteredType NodeType = 1000
+
+type nodeKind struct {
+	name     string
+	category Category
+}
+
+var builtinKinds = [...]nodeKind{
+	NodeDocument:               {"document", CategoryContainer},
+	NodeBlockQuote:             {"blockquote", CategoryContainer},
+	NodeList:                   {"list", CategoryContainer},
+	NodeListItem:               {"listitem", CategoryContainer},
+	NodeFencedDiv:              {"fenceddiv", CategoryContainer},
+	NodeCodeBlock:              {"codeblock", CategoryLeaf},
+	NodeHTMLBlock:              {"htmlblock", CategoryLeaf},
+	NodeThematicBreak:          {"thematicbreak", CategoryLeaf},
+	NodeHeading:                {"heading", CategoryLeaf},
+	NodeParagraph:              {"paragraph", CategoryLeaf},
+	NodeTOC:                    {"toc", CategoryLeaf},
+	NodeAbbreviationDefinition: {"abbreviationdefinition", CategoryLeaf},
+	NodeCodeSpan:               {"codespan", CategoryInline},
+	NodeHTMLSpan:               {"htmlspan", CategoryInline},
+	NodeEmphasis:               {"emphasis", CategoryInline},
+	NodeStrong:                 {"strong", CategoryInline},
+	NodeLink:                   {"link", CategoryInline},
+	NodeImage:                  {"image", CategoryInline},
+	NodeSoftBreak:              {"softbreak", CategoryInline},
+	NodeLineBreak:              {"linebreak", CategoryInline},
+	NodeContent:                {"text", CategoryInline},
+	NodeAbbreviation:           {"abbreviation", CategoryInline},
+	NodeStrikethrough:          {"strikethrough", CategoryInline},
+}
+
+var (
+	kindsMu         sync.RWMutex
+	registeredKinds []nodeKind
+)
+
+// RegisterNodeType allocates a node type for an extension node. It is meant
+// to be called from a package-level variable declaration:
+//
+//	var NodeMention = ast.RegisterNodeType("mention", ast.CategoryInline)
+//
+// Nodes of a registered type are visited through Visitor.VisitNode.
+// RegisterNodeType panics if name is empty or already in use.
+func RegisterNodeType(name string, category Category) NodeType {
+	if name == "" {
+		panic("ast: RegisterNodeType with empty name")
+	}
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	if nameInUse(name) {
+		panic("ast: node type " + name + " registered twice")
+	}
+	registeredKinds = append(registeredKinds, nodeKind{name, category})
+	return firstRegisteredType + NodeType(len(registeredKinds)-1)
+}
+
+// nameInUse reports whether a built-in or registered type is called name.
+// The caller must hold kindsMu.
+func nameInUse(name string) bool {
+	for _, kind := range builtinKinds {
+		if kind.name == name {
+			return true
+		}
+	}
+	for _, kind := range registeredKinds {
+		if kind.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeTypeByName looks up a built-in or registered node type by the name
+// String returns for it.
+func NodeTypeByName(name string) (NodeType, bool) {
+	for t, kind := range builtinKinds {
+		if kind.name == name {
+			return NodeType(t), true
+		}
+	}
+
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	for i, kind := range registeredKinds {
+		if kind.name == name {
+			return firstRegisteredType + NodeType(i), true
+		}
+	}
+	return 0, false
+}
+
+func (t NodeType) kind() (nodeKind, bool) {
+	if t >= 0 && int(t) < len(builtinKinds) {
+		return builtinKinds[t], true
+	}
+
+	kindsMu.RLock()
+	defer kindsMu.RUnlock()
+	if i := int(t - firstRegisteredType); i >= 0 && i < len(registeredKinds) {
+		return registeredKinds[i], true
+	}
+	return nodeKind{}, false
+}
+
+// String returns the lower-case name of the node type, such as "heading".
+func (t NodeType) String() string {
+	if kind, ok := t.kind(); ok {
+		return kind.name
+	}
+	return fmt.Sprintf("NodeType(%d)", int(t))
+}
+
+// Category returns where nodes of the type appear. Unknown types are inline.
+func (t NodeType) Category() Category {
+	kind, _ := t.kind()
+	return kind.category
+}
+
+// IsRegistered reports whether t was allocated by RegisterNodeType.
+func (t NodeType) IsRegistered() bool {
+	_, ok := t.kind()
+	return ok && t >= firstRegisteredType
+}
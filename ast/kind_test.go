@@ -0,0 +1,123 @@
+package ast
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var nodeKeyboard = RegisterNodeType("kbd", CategoryInline)
+
+var nodeCallout = RegisterNodeType("callout", CategoryContainer)
+
+type keyboard struct {
+	BaseNode
+}
+
+func (k *keyboard) Accept(v Visitor) {
+	v.VisitNode(k)
+}
+
+type nodeRecorder struct {
+	BaseVisitor
+	visited []Node
+}
+
+func (r *nodeRecorder) VisitNode(node Node) {
+	r.visited = append(r.visited, node)
+}
+
+func TestBuiltinNodeTypes(t *testing.T) {
+	tests := []struct {
+		nodeType  NodeType
+		name      string
+		container bool
+		leaf      bool
+	}{
+		{NodeDocument, "document", true, false},
+		{NodeFencedDiv, "fenceddiv", true, false},
+		{NodeCodeBlock, "codeblock", false, true},
+		{NodeAbbreviationDefinition, "abbreviationdefinition", false, true},
+		{NodeContent, "text", false, false},
+		{NodeAbbreviation, "abbreviation", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.nodeType.String(); got != tt.name {
+			t.Errorf("expected name %q, got %q", tt.name, got)
+		}
+		if got := tt.nodeType.IsContainer(); got != tt.container {
+			t.Errorf("%s: expected IsContainer %v, got %v", tt.name, tt.container, got)
+		}
+		if got := tt.nodeType.IsLeaf(); got != tt.leaf {
+			t.Errorf("%s: expected IsLeaf %v, got %v", tt.name, tt.leaf, got)
+		}
+		if got, ok := NodeTypeByName(tt.name); !ok || got != tt.nodeType {
+			t.Errorf("NodeTypeByName(%q) = %v, %v", tt.name, got, ok)
+		}
+		if tt.nodeType.IsRegistered() {
+			t.Errorf("%s: built-in type reported as registered", tt.name)
+		}
+	}
+}
+
+func TestRegisteredNodeTypes(t *testing.T) {
+	if nodeKeyboard == nodeCallout {
+		t.Fatal("registered types share a value")
+	}
+	if !nodeKeyboard.IsRegistered() || nodeKeyboard.String() != "kbd" {
+		t.Errorf("unexpected registration %v", nodeKeyboard)
+	}
+	if !nodeKeyboard.IsInline() || nodeKeyboard.IsBlock() {
+		t.Error("expected kbd to be inline")
+	}
+	if !nodeCallout.IsContainer() || !nodeCallout.IsBlock() {
+		t.Error("expected callout to be a container block")
+	}
+	if got, ok := NodeTypeByName("callout"); !ok || got != nodeCallout {
+		t.Errorf("NodeTypeByName(callout) = %v, %v", got, ok)
+	}
+}
+
+func TestRegisterNodeTypeTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	RegisterNodeType("heading", CategoryLeaf)
+}
+
+func TestRegisterNodeTypeConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	var registered atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { recover() }()
+			RegisterNodeType("racy", CategoryInline)
+			registered.Add(1)
+		}()
+	}
+	wg.Wait()
+	if n := registered.Load(); n != 1 {
+		t.Errorf("expected one registration to succeed, got %d", n)
+	}
+}
+
+func TestUnknownNodeType(t *testing.T) {
+	unknown := NodeType(-1)
+	if unknown.String() != "NodeType(-1)" || unknown.IsBlock() {
+		t.Errorf("unexpected unknown type %v", unknown)
+	}
+}
+
+func TestVisitNodeFallback(t *testing.T) {
+	kbd := &keyboard{BaseNode: New(nodeKeyboard)}
+	recorder := &nodeRecorder{}
+	kbd.Accept(recorder)
+	if len(recorder.visited) != 1 || recorder.visited[0] != kbd {
+		t.Errorf("expected VisitNode to receive the node, got %v", recorder.visited)
+	}
+}
@@ -0,0 +1,161 @@
+package ast
+
+// NodeConflict is the type of Conflict nodes. Like extension nodes, they are
+// visited through Visitor.VisitNode.
+var NodeConflict = RegisterNodeType("conflict", CategoryLeaf)
+
+// Conflict stands in for blocks that two versions of a document changed in
+// different ways. Ours and Theirs hold the blocks of each version, either of
+// which may be empty.
+type Conflict struct {
+	BaseNode
+	Ours   []Node
+	Theirs []Node
+}
+
+func NewConflict(ours, theirs []Node) *Conflict {
+	return &Conflict{
+		BaseNode: New(NodeConflict),
+		Ours:     ours,
+		Theirs:   theirs,
+	}
+}
+
+func (c *Conflict) Accept(v Visitor) {
+	v.VisitNode(c)
+}
+
+// Fields returns the blocks of each side, so that they are encoded and
+// compared with the conflict.
+func (c *Conflict) Fields() map[string]any {
+	return map[string]any{"ours": (*nodeList)(&c.Ours), "theirs": (*nodeList)(&c.Theirs)}
+}
+
+// Merge combines the changes ours and theirs made to base, block by block,
+// matching blocks as Diff does. Blocks changed on one side take that side's
+// version, and containers such as lists changed on both sides are merged
+// child by child. Where both sides changed the same blocks in different
+// ways, the merged document holds a Conflict with each side's version of the
+// top-level blocks involved. Changes to formatting alone count as no change.
+//
+// Merge returns the merged document and its number of conflicts. It moves
+// nodes of ours and theirs into the result, so those trees should not be
+// used afterwards; pass a Clone of any that are still needed.
+func Merge(base, ours, theirs *Document) (*Document, int) {
+	d := &differ{keys: make(map[Node]uint64)}
+	doc := NewDocument()
+	conflicts := 0
+	for _, node := range d.mergeChildren(base, ours, theirs) {
+		if conflict, ok := node.(*Conflict); ok {
+			for _, side := range [][]Node{conflict.Ours, conflict.Theirs} {
+				for _, block := range side {
+					detach(block)
+				}
+			}
+			conflicts++
+		}
+		detach(node)
+		doc.AddChild(node)
+	}
+	return doc, conflicts
+}
+
+// mergeChildren merges the children of three versions of a node. Children
+// matched in all three are merged in turn, and the runs of children between
+// them are taken from the side that changed them.
+func (d *differ) mergeChildren(base, ours, theirs Node) []Node {
+	b, o, t := base.Children(), ours.Children(), theirs.Children()
+	inOurs := make(map[int]int)
+	for _, pair := range d.match(b, o) {
+		inOurs[pair[0]] = pair[1]
+	}
+	inTheirs := make(map[int]int)
+	for _, pair := range d.match(b, t) {
+		inTheirs[pair[0]] = pair[1]
+	}
+
+	var merged []Node
+	bi, oi, ti := 0, 0, 0
+	for i := range b {
+		oj, inO := inOurs[i]
+		tj, inT := inTheirs[i]
+		if !inO || !inT {
+			continue
+		}
+		merged = append(merged, d.mergeRun(b[bi:i], o[oi:oj], t[ti:tj])...)
+		merged = append(merged, d.mergeNode(b[i], o[oj], t[tj]))
+		bi, oi, ti = i+1, oj+1, tj+1
+	}
+	return append(merged, d.mergeRun(b[bi:], o[oi:], t[ti:])...)
+}
+
+// mergeRun merges runs of nodes that have no match in all three versions.
+func (d *differ) mergeRun(base, ours, theirs []Node) []Node {
+	switch {
+	case d.same(ours, base):
+		return theirs
+	case d.same(theirs, base), d.same(ours, theirs):
+		return ours
+	}
+	return []Node{NewConflict(ours, theirs)}
+}
+
+// mergeNode merges three versions of a node.
+func (d *differ) mergeNode(base, ours, theirs Node) Node {
+	switch {
+	case d.key(ours) == d.key(base):
+		return theirs
+	case d.key(theirs) == d.key(base), d.key(ours) == d.key(theirs):
+		return ours
+	}
+
+	if ours.Type().IsContainer() {
+		fieldsBase, fieldsOurs, fieldsTheirs := semanticFields(base), semanticFields(ours), semanticFields(theirs)
+		if fieldsOurs == fieldsBase || fieldsTheirs == fieldsBase || fieldsOurs == fieldsTheirs {
+			if children := d.mergeChildren(base, ours, theirs); !hasConflict(children) {
+				merged := ours
+				if fieldsOurs == fieldsBase {
+					merged = theirs
+				}
+				for _, child := range merged.Children() {
+					merged.RemoveChild(child)
+				}
+				for _, child := range children {
+					detach(child)
+					merged.AddChild(child)
+				}
+				return merged
+			}
+		}
+	}
+	return NewConflict([]Node{ours}, []Node{theirs})
+}
+
+// same reports whether two runs of nodes differ only in formatting.
+func (d *differ) same(a, b []Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if d.key(a[i]) != d.key(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasConflict(nodes []Node) bool {
+	for _, node := range nodes {
+		if _, ok := node.(*Conflict); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// detach removes node from its parent, if it has one.
+func detach(node Node) {
+	if parent := node.Parent(); parent != nil {
+		parent.RemoveChild(node)
+	}
+}
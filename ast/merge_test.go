@@ -0,0 +1,133 @@
+package ast
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mergeTestDocument builds a document with a paragraph for each text, and a
+// block quote holding a paragraph for each text after a "> " marker.
+func mergeTestDocument(texts ...string) *Document {
+	doc := NewDocument()
+	var quote *BlockQuote
+	for _, text := range texts {
+		if quoted, ok := strings.CutPrefix(text, "> "); ok {
+			if quote == nil {
+				quote = NewBlockQuote()
+				doc.AddChild(quote)
+			}
+			quote.AddChild(diffTestParagraph(quoted))
+			continue
+		}
+		quote = nil
+		doc.AddChild(diffTestParagraph(text))
+	}
+	return doc
+}
+
+// blockTexts lists the text of each paragraph below node, with conflicts
+// written as "ours|theirs".
+func blockTexts(node Node) []string {
+	var texts []string
+	for _, child := range node.Children() {
+		switch child := child.(type) {
+		case *Paragraph:
+			texts = append(texts, textOf(child))
+		case *Conflict:
+			var ours, theirs []string
+			for _, block := range child.Ours {
+				ours = append(ours, strings.TrimSpace(textOf(block)))
+			}
+			for _, block := range child.Theirs {
+				theirs = append(theirs, strings.TrimSpace(textOf(block)))
+			}
+			texts = append(texts, strings.Join(ours, " / ")+"|"+strings.Join(theirs, " / "))
+		default:
+			for _, text := range blockTexts(child) {
+				texts = append(texts, "> "+text)
+			}
+		}
+	}
+	return texts
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs []string
+		expected           []string
+		conflicts          int
+	}{
+		{"different paragraphs",
+			[]string{"one two three", "four five six", "seven eight nine"},
+			[]string{"one two three changed", "four five six", "seven eight nine"},
+			[]string{"one two three", "four five six", "seven eight nine changed"},
+			[]string{"one two three changed", "four five six", "seven eight nine changed"}, 0},
+		{"insertions",
+			[]string{"one two three", "four five six"},
+			[]string{"new first", "one two three", "four five six"},
+			[]string{"one two three", "four five six", "new last"},
+			[]string{"new first", "one two three", "four five six", "new last"}, 0},
+		{"same change",
+			[]string{"one two three", "four five six"},
+			[]string{"one two three", "four five six seven"},
+			[]string{"one two three", "four five six seven"},
+			[]string{"one two three", "four five six seven"}, 0},
+		{"deleted and unchanged",
+			[]string{"one two three", "four five six"},
+			[]string{"four five six"},
+			[]string{"one two three", "four five six"},
+			[]string{"four five six"}, 0},
+		{"conflict",
+			[]string{"one two three", "four five six"},
+			[]string{"one two three ours", "four five six"},
+			[]string{"one two three theirs", "four five six"},
+			[]string{"one two three ours|one two three theirs", "four five six"}, 1},
+		{"deleted and changed",
+			[]string{"one two three", "four five six"},
+			[]string{"four five six"},
+			[]string{"one two three theirs", "four five six"},
+			[]string{"|one two three theirs", "four five six"}, 1},
+		{"inside container",
+			[]string{"intro text", "> one two three", "> four five six"},
+			[]string{"intro text", "> one two three ours", "> four five six"},
+			[]string{"intro text", "> one two three", "> four five six theirs"},
+			[]string{"intro text", "> one two three ours", "> four five six theirs"}, 0},
+		{"conflict inside container",
+			[]string{"intro text", "> one two three", "> four five six"},
+			[]string{"intro text", "> one two three ours", "> four five six"},
+			[]string{"intro text", "> one two three theirs", "> four five six"},
+			[]string{"intro text", "one two three ours four five six|one two three theirs four five six"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, ours, theirs := mergeTestDocument(tt.base...), mergeTestDocument(tt.ours...), mergeTestDocument(tt.theirs...)
+			merged, conflicts := Merge(base, ours, theirs)
+			if conflicts != tt.conflicts {
+				t.Errorf("expected %d conflicts, got %d", tt.conflicts, conflicts)
+			}
+			if texts := blockTexts(merged); !slices.Equal(texts, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, texts)
+			}
+		})
+	}
+}
+
+func TestMergeDetachesNodes(t *testing.T) {
+	base := mergeTestDocument("one two three", "four five six")
+	ours := mergeTestDocument("one two three ours", "four five six")
+	theirs := mergeTestDocument("one two three theirs", "four five six")
+	merged, _ := Merge(base, ours, theirs)
+
+	for node := range All(merged) {
+		if node != merged && node.Parent() == nil {
+			t.Errorf("%s has no parent", node.Type())
+		}
+	}
+	conflict := merged.FirstChild().(*Conflict)
+	if conflict.Ours[0].Parent() != nil || conflict.Theirs[0].Parent() != nil {
+		t.Errorf("expected the sides of a conflict to be detached")
+	}
+}
@@ -1,3 +1,7 @@
+// Package ast defines the document tree produced by the parser. Every node
+// embeds BaseNode, which links it to its parent and siblings, and accepts a
+// Visitor for traversal. Walk and the All, Descendants and Ancestors
+// iterators traverse a tree without writing a Visitor.
 package ast
 
 type Node interface {
@@ -9,6 +13,8 @@ type Node interface {
 	NextSibling() Node
     setPrevSibling(Node)
     setNextSibling(Node)
+	setSelf(Node)
+	baseNode() *BaseNode
 
 	FirstChild() Node
 	LastChild() Node
@@ -21,6 +27,18 @@ type Node interface {
 	IsOpen() bool
 	SetOpen(bool)
 
+	Pos() Position
+	SetPos(Position)
+
+	Attrs() []Attribute
+	Attr(key string) (string, bool)
+	SetAttr(key, value string)
+	DeleteAttr(key string)
+
+	Data(key any) (any, bool)
+	SetData(key, value any)
+	DeleteData(key any)
+
 	Accept(Visitor)
 }
 
@@ -32,6 +50,13 @@ type BaseNode struct {
 	nextSibling Node
 	nodeType    NodeType
 	isOpen      bool
+	pos         Position
+	attrs       []Attribute
+	data        map[any]any
+
+	// self is the node embedding this BaseNode, recorded when it is linked
+	// into a tree so that Parent returns the concrete node.
+	self Node
 }
 
 func New(t NodeType) BaseNode {
@@ -74,6 +99,34 @@ func (n *BaseNode) setNextSibling(next Node) {
     n.nextSibling = next
 }
 
+// node returns the node embedding n if it is known, or n itself.
+func (n *BaseNode) node() Node {
+	if n.self != nil {
+		return n.self
+	}
+	return n
+}
+
+func (n *BaseNode) baseNode() *BaseNode {
+	return n
+}
+
+func (n *BaseNode) setSelf(self Node) {
+	if n.self == self {
+		return
+	}
+	n.self = self
+	for child := n.firstChild; child != nil; child = child.NextSibling() {
+		child.SetParent(self)
+	}
+}
+
+// adopt makes child point at n as its parent.
+func (n *BaseNode) adopt(child Node) {
+	child.setSelf(child)
+	child.SetParent(n.node())
+}
+
 func (n *BaseNode) FirstChild() Node {
 	return n.firstChild
 }
@@ -91,7 +144,7 @@ func (n *BaseNode) Children() []Node {
 }
 
 func (n *BaseNode) AddChild(child Node) {
-	child.SetParent(n)
+	n.adopt(child)
 
 	if n.lastChild != nil {
 		n.lastChild.setNextSibling(child)
@@ -107,7 +160,7 @@ func (n *BaseNode) AddChild(child Node) {
 }
 
 func (n *BaseNode) RemoveChild(child Node) {
-	if child.Parent() != n {
+	if child.Parent() != n.node() {
 		return
 	}
 
@@ -129,10 +182,10 @@ func (n *BaseNode) RemoveChild(child Node) {
 }
 
 func (n *BaseNode) InsertAfter(oldNode, newNode Node) {
-	if oldNode.Parent() != n {
+	if oldNode.Parent() != n.node() {
 		return
 	}
-	newNode.SetParent(n)
+	n.adopt(newNode)
 
 	newNode.setPrevSibling(oldNode)
 	newNode.setNextSibling(oldNode.NextSibling())
@@ -147,11 +200,11 @@ func (n *BaseNode) InsertAfter(oldNode, newNode Node) {
 }
 
 func (n *BaseNode) ReplaceChild(oldNode, newNode Node) {
-	if oldNode.Parent() != n {
+	if oldNode.Parent() != n.node() {
 		return
 	}
 
-	newNode.SetParent(n)
+	n.adopt(newNode)
 
 	newNode.setPrevSibling(oldNode.PrevSibling())
 	newNode.setNextSibling(oldNode.NextSibling())
@@ -194,6 +247,7 @@ const (
 	NodeBlockQuote
 	NodeList
 	NodeListItem
+	NodeFencedDiv
 
 	// Leaf blocks are block nodes that cannot have other blocks as children.
 	// See: https://spec.commonmark.org/0.31.2/#leaf-blocks
@@ -202,6 +256,8 @@ const (
 	NodeThematicBreak
 	NodeHeading
 	NodeParagraph
+	NodeTOC
+	NodeAbbreviationDefinition
 
 	// Inlines are parsed horizontally from a one-line string.
 	// See: https://spec.commonmark.org/0.31.2/#inlines
@@ -214,14 +270,16 @@ const (
 	NodeSoftBreak
 	NodeLineBreak
 	NodeContent
+	NodeAbbreviation
+	NodeStrikethrough
 )
 
 func (t NodeType) IsLeaf() bool {
-	return t >= NodeCodeBlock && t <= NodeParagraph
+	return t.Category() == CategoryLeaf
 }
 
 func (t NodeType) IsContainer() bool {
-	return t >= NodeDocument && t <= NodeListItem
+	return t.Category() == CategoryContainer
 }
 
 func (t NodeType) IsBlock() bool {
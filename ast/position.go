@@ -0,0 +1,43 @@
+package ast
+
+import "fmt"
+
+// Point is a location in the source text. Line and Column count from 1, with
+// columns measured in bytes; Offset is the byte offset from the start of the
+// input.
+type Point struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// Position is the source range a node was parsed from, from Start up to but
+// not including End. Nodes built by hand have the zero Position.
+type Position struct {
+	Start Point
+	End   Point
+}
+
+// IsValid reports whether the position was set by the parser.
+func (p Position) IsValid() bool {
+	return p.Start.Line > 0
+}
+
+// String formats the position as cmark does for sourcepos, with an
+// inclusive end column: "3:1-5:12".
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d-%d:%d", p.Start.Line, p.Start.Column, p.End.Line, max(p.End.Column-1, 0))
+}
+
+// Pos returns the source position of the node.
+func (n *BaseNode) Pos() Position {
+	return n.pos
+}
+
+// SetPos sets the source position of the node.
+func (n *BaseNode) SetPos(pos Position) {
+	n.pos = pos
+}
@@ -0,0 +1,420 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Selector is a compiled CSS-like query over a tree, as taken by Query. A
+// selector names node types and may add conditions in brackets:
+//
+//	heading[level=2] + paragraph
+//	list > listitem link[destination^="http"]
+//	codeblock[language=go], htmlblock
+//
+// Types are the names NodeType.String returns, or * for any node. Conditions
+// compare the node's fields, under the names MarshalJSON gives them, or else
+// its attributes: [name] requires a non-empty value, and [name=value],
+// [name!=value], [name^=prefix], [name$=suffix], [name*=part] and [name~=word]
+// compare it as a string. Values may be quoted. The pseudo-classes
+// :first-child, :last-child, :empty and :contains("text") are supported, and
+// the combinators are descendant (a space), child (>), next sibling (+) and
+// later sibling (~).
+type Selector struct {
+	alternatives [][]compound
+}
+
+// compound is a type and conditions a single node must meet, and how it
+// relates to the node matched by the compound before it.
+type compound struct {
+	combinator byte
+	anyType    bool
+	nodeType   NodeType
+	conditions []condition
+}
+
+type condition struct {
+	pseudo string
+	name   string
+	op     string
+	value  string
+}
+
+// CompileSelector parses a selector, as described for Selector.
+func CompileSelector(selector string) (*Selector, error) {
+	p := &selectorParser{input: selector}
+	s, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("ast: selector %q: %w", selector, err)
+	}
+	return s, nil
+}
+
+// Query returns the nodes in the tree rooted at root, itself included, that
+// match selector, in document order.
+func Query(root Node, selector string) ([]Node, error) {
+	s, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	return s.Select(root), nil
+}
+
+// Select returns the nodes in the tree rooted at root, itself included, that
+// match the selector, in document order. Each node is returned once, even in
+// a malformed tree that reaches it twice.
+func (s *Selector) Select(root Node) []Node {
+	var matches []Node
+	seen := make(map[Node]bool)
+	for node := range All(root) {
+		if !seen[node] && s.Match(node) {
+			matches = append(matches, node)
+		}
+		seen[node] = true
+	}
+	return matches
+}
+
+// Match reports whether node matches the selector. Ancestors and siblings
+// are looked up in whatever tree node is in.
+func (s *Selector) Match(node Node) bool {
+	for _, compounds := range s.alternatives {
+		if matchCompounds(node, compounds) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCompounds matches node against the last of compounds, and its
+// relatives against the others, from right to left.
+func matchCompounds(node Node, compounds []compound) bool {
+	last := compounds[len(compounds)-1]
+	if !last.matches(node) {
+		return false
+	}
+	rest := compounds[:len(compounds)-1]
+	if len(rest) == 0 {
+		return true
+	}
+
+	switch last.combinator {
+	case '>':
+		return node.Parent() != nil && matchCompounds(node.Parent(), rest)
+	case '+':
+		return node.PrevSibling() != nil && matchCompounds(node.PrevSibling(), rest)
+	case '~':
+		for sibling := node.PrevSibling(); sibling != nil; sibling = sibling.PrevSibling() {
+			if matchCompounds(sibling, rest) {
+				return true
+			}
+		}
+	default:
+		for ancestor := node.Parent(); ancestor != nil; ancestor = ancestor.Parent() {
+			if matchCompounds(ancestor, rest) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c compound) matches(node Node) bool {
+	if !c.anyType && node.Type() != c.nodeType {
+		return false
+	}
+	for _, cond := range c.conditions {
+		if !cond.matches(node) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) matches(node Node) bool {
+	switch c.pseudo {
+	case "first-child":
+		return node.Parent() != nil && node.PrevSibling() == nil
+	case "last-child":
+		return node.Parent() != nil && node.NextSibling() == nil
+	case "empty":
+		return node.FirstChild() == nil
+	case "contains":
+		return strings.Contains(textOf(node), c.value)
+	}
+
+	value, ok := selectorValue(node, c.name)
+	if !ok {
+		return c.op == "!="
+	}
+	switch c.op {
+	case "":
+		return value != ""
+	case "=":
+		return value == c.value
+	case "!=":
+		return value != c.value
+	case "^=":
+		return c.value != "" && strings.HasPrefix(value, c.value)
+	case "$=":
+		return c.value != "" && strings.HasSuffix(value, c.value)
+	case "*=":
+		return c.value != "" && strings.Contains(value, c.value)
+	case "~=":
+		return slices.Contains(strings.Fields(value), c.value)
+	}
+	return false
+}
+
+// selectorValue returns the field of node called name as a string, or else
+// its attribute called name.
+func selectorValue(node Node, name string) (string, bool) {
+	if field, ok := fieldsOf(node)[name]; ok {
+		switch field := field.(type) {
+		case *string:
+			return *field, true
+		case *char:
+			if *field == 0 {
+				return "", true
+			}
+			return string(rune(*field)), true
+		}
+		if v := reflect.ValueOf(field).Elem(); v.Kind() == reflect.Bool {
+			if !v.Bool() {
+				return "", true
+			}
+		}
+		encoded, err := json.Marshal(field)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	}
+	return node.Attr(name)
+}
+
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *selectorParser) parse() (*Selector, error) {
+	s := &Selector{}
+	for {
+		compounds, err := p.parseComplex()
+		if err != nil {
+			return nil, err
+		}
+		s.alternatives = append(s.alternatives, compounds)
+		if p.done() {
+			return s, nil
+		}
+		p.pos++ // the comma that ended the complex selector
+	}
+}
+
+// parseComplex parses compound selectors joined by combinators, up to a comma
+// or the end of the input.
+func (p *selectorParser) parseComplex() ([]compound, error) {
+	var compounds []compound
+	combinator := byte(0)
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ',' {
+			if combinator != 0 && combinator != ' ' || len(compounds) == 0 {
+				return nil, p.errorf("expected a selector")
+			}
+			return compounds, nil
+		}
+		if strings.IndexByte(">+~", p.peek()) >= 0 {
+			if combinator != 0 && combinator != ' ' || len(compounds) == 0 {
+				return nil, p.errorf("unexpected %q", p.peek())
+			}
+			combinator = p.peek()
+			p.pos++
+			continue
+		}
+		if len(compounds) > 0 && combinator == 0 {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+
+		c, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		c.combinator = combinator
+		compounds = append(compounds, c)
+
+		combinator = 0
+		if p.skipSpace() {
+			combinator = ' '
+		}
+	}
+}
+
+func (p *selectorParser) parseCompound() (compound, error) {
+	c := compound{anyType: true}
+	hasType := true
+	switch {
+	case p.peek() == '*':
+		p.pos++
+	case isNameByte(p.peek()):
+		start := p.pos
+		name := p.name()
+		t, ok := NodeTypeByName(strings.ToLower(name))
+		if !ok {
+			p.pos = start
+			return c, p.errorf("unknown node type %q", name)
+		}
+		c.anyType, c.nodeType = false, t
+	default:
+		hasType = false
+	}
+
+	for {
+		var cond condition
+		var err error
+		switch p.peek() {
+		case '[':
+			cond, err = p.parseAttribute()
+		case ':':
+			cond, err = p.parsePseudo()
+		default:
+			if !hasType && len(c.conditions) == 0 {
+				return c, p.errorf("unexpected %q", p.peek())
+			}
+			return c, nil
+		}
+		if err != nil {
+			return c, err
+		}
+		c.conditions = append(c.conditions, cond)
+	}
+}
+
+// parseAttribute parses a condition in brackets.
+func (p *selectorParser) parseAttribute() (condition, error) {
+	var cond condition
+	p.pos++
+	p.skipSpace()
+	if cond.name = p.name(); cond.name == "" {
+		return cond, p.errorf("expected a field name")
+	}
+	p.skipSpace()
+	for _, op := range []string{"=", "!=", "^=", "$=", "*=", "~="} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			cond.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if cond.op != "" {
+		p.skipSpace()
+		value, err := p.value()
+		if err != nil {
+			return cond, err
+		}
+		cond.value = value
+		p.skipSpace()
+	}
+	if p.peek() != ']' {
+		return cond, p.errorf("expected ]")
+	}
+	p.pos++
+	return cond, nil
+}
+
+func (p *selectorParser) parsePseudo() (condition, error) {
+	start := p.pos
+	p.pos++
+	cond := condition{pseudo: p.name()}
+	switch cond.pseudo {
+	case "first-child", "last-child", "empty":
+		return cond, nil
+	case "contains":
+		if p.peek() != '(' {
+			return cond, p.errorf("expected (")
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.value()
+		if err != nil {
+			return cond, err
+		}
+		cond.value = value
+		p.skipSpace()
+		if p.peek() != ')' {
+			return cond, p.errorf("expected )")
+		}
+		p.pos++
+		return cond, nil
+	}
+	p.pos = start
+	return cond, p.errorf("unknown pseudo-class %q", ":"+cond.pseudo)
+}
+
+// value parses a quoted string or a bare name.
+func (p *selectorParser) value() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		if value := p.name(); value != "" {
+			return value, nil
+		}
+		return "", p.errorf("expected a value")
+	}
+
+	var b strings.Builder
+	for p.pos++; !p.done(); p.pos++ {
+		switch ch := p.input[p.pos]; {
+		case ch == quote:
+			p.pos++
+			return b.String(), nil
+		case ch == '\\' && p.pos+1 < len(p.input):
+			p.pos++
+			b.WriteByte(p.input[p.pos])
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *selectorParser) name() string {
+	start := p.pos
+	for !p.done() && isNameByte(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// skipSpace skips spaces, reporting whether there were any.
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t\n", p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *selectorParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *selectorParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func isNameByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		ch == '-' || ch == '_' || ch == '.'
+}
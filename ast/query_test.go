@@ -0,0 +1,108 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func queryTestDocument(t *testing.T) *Document {
+	t.Helper()
+	doc, err := Build().
+		Heading(1, "Guide").
+		Paragraph("Intro with ", NewLink("https://example.com", "", Text("a site")), ".").
+		Heading(2, "Install").
+		Paragraph("Run ", NewLink("/docs/install", "", Text("the installer")), ".").
+		List("Fast", Build().Paragraph("See ", NewLink("http://old.example.com", "", Text("old docs")))).
+		CodeBlock("go", "fmt.Println()").
+		Heading(2, "Usage").
+		CodeBlock("sh", "markee fmt").
+		Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.LastChild().SetAttr("class", "shell example")
+	return doc
+}
+
+func TestQuery(t *testing.T) {
+	doc := queryTestDocument(t)
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"heading[level=2] + paragraph", []string{"Run the installer."}},
+		{`list > listitem link[destination^="http"]`, []string{"old docs"}},
+		{"codeblock[language=go]", []string{"fmt.Println()"}},
+		{"heading[level=2]", []string{"Install", "Usage"}},
+		{"heading ~ codeblock[language=sh]", []string{"markee fmt"}},
+		{"link[destination*=example]", []string{"a site", "old docs"}},
+		{"link[destination$='/install']", []string{"the installer"}},
+		{"codeblock[class~=shell]", []string{"markee fmt"}},
+		{"codeblock[language!=go]", []string{"markee fmt"}},
+		{"document > paragraph link, codeblock[language=sh]", []string{"a site", "the installer", "markee fmt"}},
+		{"heading:first-child", []string{"Guide"}},
+		{"paragraph > *:last-child", []string{".", ".", "Fast", "old docs"}},
+		{`paragraph:contains("installer")`, []string{"Run the installer."}},
+		{"list[tight]", []string{"Fast See old docs"}},
+		{"heading[level=3]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			matches, err := Query(doc, tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, node := range matches {
+				got = append(got, strings.Join(strings.Fields(textOf(node)), " "))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		err      string
+	}{
+		{"", "expected a selector"},
+		{"heading >", "expected a selector"},
+		{"> heading", `unexpected '>'`},
+		{"heading,,paragraph", "expected a selector"},
+		{"heading section", `offset 8: unknown node type "section"`},
+		{"heading[level=2", "expected ]"},
+		{`link[title="x]`, "unterminated string"},
+		{"heading:nth-child(2)", `offset 7: unknown pseudo-class ":nth-child"`},
+		{"heading#intro", `unexpected '#'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			_, err := Query(NewDocument(), tt.selector)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestQueryReturnsNodesOnce(t *testing.T) {
+	link := NewLink("x", "", Text("l"))
+	first, second := NewParagraph(), NewParagraph()
+	first.AddChild(link)
+	// A malformed tree that reaches the link from both paragraphs.
+	second.AddChild(link)
+	doc := NewDocument()
+	doc.AddChild(first)
+	doc.AddChild(second)
+
+	matches, err := Query(doc, "paragraph > link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Errorf("expected the link once, got %d matches", len(matches))
+	}
+}
@@ -0,0 +1,88 @@
+package ast
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Snapshot is the state of a node when TakeSnapshot was called: the text it
+// was parsed from, its position there and its children. Printing a node that
+// still matches its snapshot can copy the original text, with every marker,
+// escape and blank line the author wrote.
+type Snapshot struct {
+	// Source is the whole text the tree was parsed from.
+	Source   string
+	Pos      Position
+	Children []Node
+
+	fields string
+}
+
+var snapshotKey = NewDataKey[*Snapshot]("snapshot")
+
+// TakeSnapshot records a Snapshot of root and every node below it, taken
+// against source. The parser does this in lossless mode.
+func TakeSnapshot(root Node, source string) {
+	for node := range All(root) {
+		snapshotKey.Set(node, &Snapshot{
+			Source:   source,
+			Pos:      node.Pos(),
+			Children: node.Children(),
+			fields:   fingerprint(node),
+		})
+	}
+}
+
+// SnapshotOf returns the snapshot recorded for node, if any.
+func SnapshotOf(node Node) (*Snapshot, bool) {
+	return snapshotKey.Get(node)
+}
+
+// Change describes how a node differs from its snapshot.
+type Change int
+
+const (
+	// ChangedFields means the node's fields or attributes were set.
+	ChangedFields Change = 1 << iota
+	// ChangedChildren means children were added, removed or reordered.
+	// Changes further down the tree are not included.
+	ChangedChildren
+)
+
+// Changes compares node with its snapshot. A node without one, such as a node
+// built after parsing, has changed in every way.
+func Changes(node Node) Change {
+	snapshot, ok := SnapshotOf(node)
+	if !ok {
+		return ChangedFields | ChangedChildren
+	}
+
+	var changes Change
+	if fingerprint(node) != snapshot.fields {
+		changes |= ChangedFields
+	}
+	if !slices.Equal(node.Children(), snapshot.Children) {
+		changes |= ChangedChildren
+	}
+	return changes
+}
+
+// fingerprint encodes the fields and attributes of node. Fields that hold
+// nodes are left out, since their encoding includes positions; Equal and
+// Hash compare them as trees.
+func fingerprint(node Node) string {
+	fields := fieldsOf(node)
+	for name, field := range fields {
+		if _, ok := field.(*nodeList); ok {
+			delete(fields, name)
+		}
+	}
+	data, err := json.Marshal(struct {
+		Fields     map[string]any `json:"fields"`
+		Attributes []Attribute    `json:"attributes"`
+	}{fields, node.Attrs()})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
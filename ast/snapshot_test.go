@@ -0,0 +1,41 @@
+package ast
+
+import "testing"
+
+func TestChanges(t *testing.T) {
+	doc := walkTestDocument()
+	TakeSnapshot(doc, "# Title\n\na *b*")
+	heading := doc.FirstChild().(*Heading)
+	paragraph := doc.LastChild()
+
+	for node := range All(doc) {
+		if changes := Changes(node); changes != 0 {
+			t.Fatalf("expected no changes to %s, got %v", node.Type(), changes)
+		}
+	}
+	if snapshot, ok := SnapshotOf(heading); !ok || snapshot.Source != "# Title\n\na *b*" || len(snapshot.Children) != 1 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+
+	heading.Level = 2
+	if changes := Changes(heading); changes != ChangedFields {
+		t.Errorf("expected ChangedFields, got %v", changes)
+	}
+	heading.Level = 1
+	heading.SetAttr("class", "x")
+	if changes := Changes(heading); changes != ChangedFields {
+		t.Errorf("expected ChangedFields after SetAttr, got %v", changes)
+	}
+
+	added := NewContent("c")
+	paragraph.AddChild(added)
+	if changes := Changes(paragraph); changes != ChangedChildren {
+		t.Errorf("expected ChangedChildren, got %v", changes)
+	}
+	if changes := Changes(doc); changes != 0 {
+		t.Errorf("expected changes below the document to be left out, got %v", changes)
+	}
+	if changes := Changes(added); changes != ChangedFields|ChangedChildren {
+		t.Errorf("expected a new node to have changed, got %v", changes)
+	}
+}
@@ -0,0 +1,184 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ValidationError is a structural rule that a node breaks.
+type ValidationError struct {
+	Node Node
+	// Pos is the position of the node, or of its nearest ancestor that has
+	// one, since nodes added by transforms often have none.
+	Pos     Position
+	Message string
+}
+
+// Error prefixes the message with the position, if there is one:
+// "3:1-3:24: link inside a link".
+func (e *ValidationError) Error() string {
+	if !e.Pos.IsValid() {
+		return e.Message
+	}
+	return e.Pos.String() + ": " + e.Message
+}
+
+// ValidationErrors lists the rules a tree breaks, in document order.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Rule checks a node of the type it was registered for, returning what is
+// wrong with it or nil.
+type Rule func(node Node) error
+
+var (
+	rulesMu sync.RWMutex
+	rules   = make(map[NodeType][]Rule)
+)
+
+// RegisterRule adds a rule that Validate applies to nodes of type t, so that
+// an extension can declare what its nodes need beyond the rules for their
+// category.
+func RegisterRule(t NodeType, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[t] = append(rules[t], rule)
+}
+
+// Validate checks the tree rooted at root against the structure CommonMark
+// gives documents, and against the rules registered for its node types. It
+// returns nil for a valid tree, or ValidationErrors listing every problem:
+//
+//   - paragraphs and headings hold only inline nodes, and other built-in
+//     leaf blocks only text;
+//   - container blocks hold only blocks, and inline nodes only inline ones;
+//   - lists hold only list items, and list items appear only in lists;
+//   - no link holds another, and a document is only ever the root;
+//   - heading levels are between 1 and 6;
+//   - every child has its parent as Parent.
+//
+// Nodes of registered types follow the rules for their category.
+func Validate(root Node) error {
+	v := &validator{}
+	v.check(root, isLink(root))
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// validateChild validates node as a new child of parent, returning the first
+// problem.
+func validateChild(parent, node Node) error {
+	v := &validator{}
+	if message := misplaced(parent, node); message != "" {
+		v.report(node, "%s", message)
+	}
+	inLink := false
+	for ancestor := parent; ancestor != nil; ancestor = ancestor.Parent() {
+		inLink = inLink || isLink(ancestor)
+	}
+	if isLink(node) && inLink {
+		v.report(node, "link inside a link")
+	}
+	v.check(node, inLink || isLink(node))
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("ast: %w", v.errs[0])
+}
+
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) report(node Node, format string, args ...any) {
+	pos := Position{}
+	for n := node; n != nil && !pos.IsValid(); n = n.Parent() {
+		pos = n.Pos()
+	}
+	v.errs = append(v.errs, &ValidationError{Node: node, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates node and its descendants, inLink telling whether node is
+// or is inside a link.
+func (v *validator) check(node Node, inLink bool) {
+	if heading, ok := node.(*Heading); ok && (heading.Level < 1 || heading.Level > 6) {
+		v.report(node, "heading level %d is not between 1 and 6", heading.Level)
+	}
+
+	rulesMu.RLock()
+	typeRules := rules[node.Type()]
+	rulesMu.RUnlock()
+	for _, rule := range typeRules {
+		if err := rule(node); err != nil {
+			v.report(node, "%s", err.Error())
+		}
+	}
+
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if parent := child.Parent(); parent == nil || parent.baseNode() != node.baseNode() {
+			v.report(child, "%s has the wrong parent", child.Type())
+		}
+		if message := misplaced(node, child); message != "" {
+			v.report(child, "%s", message)
+		}
+		if isLink(child) && inLink {
+			v.report(child, "link inside a link")
+		}
+		v.check(child, inLink || isLink(child))
+	}
+}
+
+// misplaced describes what is wrong with child being a child of parent, or
+// returns "" if nothing is.
+func misplaced(parent, child Node) string {
+	parentType, childType := parent.Type(), child.Type()
+	var ok bool
+	switch {
+	case childType == NodeDocument:
+		return "document below the root"
+	case childType == NodeListItem && parentType != NodeList:
+		return "list item outside a list"
+	case parentType == NodeList:
+		if childType != NodeListItem {
+			return fmt.Sprintf("%s inside a list", childType)
+		}
+		return ""
+	case parentType.IsContainer():
+		ok = childType.IsBlock()
+	case parentType == NodeParagraph, parentType == NodeHeading, parentType.IsRegistered():
+		ok = childType.IsInline()
+	case parentType.IsLeaf():
+		ok = childType == NodeContent
+	default:
+		ok = childType.IsInline() && holdsInlines(parentType)
+	}
+	if !ok {
+		return fmt.Sprintf("%s cannot contain %s", parentType, childType)
+	}
+	return ""
+}
+
+// holdsInlines reports whether nodes of the built-in inline type t have
+// children.
+func holdsInlines(t NodeType) bool {
+	switch t {
+	case NodeEmphasis, NodeStrong, NodeLink, NodeAbbreviation, NodeStrikethrough:
+		return true
+	}
+	return false
+}
+
+func isLink(node Node) bool {
+	_, ok := node.(*Link)
+	return ok
+}
@@ -0,0 +1,109 @@
+package ast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := Validate(cloneTestDocument()); err != nil {
+		t.Fatalf("expected a valid document, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(doc *Document)
+		message string
+	}{
+		{"link in link", func(doc *Document) {
+			link := doc.LastChild().PrevSibling().FirstChild()
+			link.AddChild(NewEmphasis(NewLink("/inner", "")))
+		}, "link inside a link"},
+		{"block in paragraph", func(doc *Document) {
+			doc.FirstChild().NextSibling().AddChild(NewThematicBreak())
+		}, "paragraph cannot contain thematicbreak"},
+		{"item outside list", func(doc *Document) {
+			doc.AddChild(NewListItem(0))
+		}, "list item outside a list"},
+		{"list child", func(doc *Document) {
+			doc.AddChild(listWith(NewParagraph()))
+		}, "paragraph inside a list"},
+		{"inline in container", func(doc *Document) {
+			doc.AddChild(NewContent("loose"))
+		}, "document cannot contain text"},
+		{"code block child", func(doc *Document) {
+			doc.LastChild().AddChild(NewStrong())
+		}, "codeblock cannot contain strong"},
+		{"inline leaf child", func(doc *Document) {
+			doc.FirstChild().FirstChild().AddChild(NewContent("x"))
+		}, "text cannot contain text"},
+		{"nested document", func(doc *Document) {
+			doc.AddChild(blockQuoteWith(NewDocument()))
+		}, "document below the root"},
+		{"heading level", func(doc *Document) {
+			doc.FirstChild().(*Heading).Level = 9
+		}, "heading level 9"},
+		{"shared node", func(doc *Document) {
+			text := doc.FirstChild().FirstChild()
+			paragraph := NewParagraph()
+			doc.AddChild(paragraph)
+			paragraph.AddChild(text)
+		}, "text has the wrong parent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := cloneTestDocument()
+			tt.change(doc)
+			err := Validate(doc)
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected %q, got %q", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestValidatePositions(t *testing.T) {
+	doc := cloneTestDocument()
+	paragraph := doc.FirstChild().NextSibling()
+	paragraph.SetPos(Position{Start: Point{3, 1, 10}, End: Point{3, 20, 29}})
+	paragraph.AddChild(NewParagraph())
+
+	errs := Validate(doc).(ValidationErrors)
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, got %v", errs)
+	}
+	if errs[0].Node != paragraph.LastChild() {
+		t.Errorf("expected the error on the misplaced node")
+	}
+	if got := errs[0].Error(); got != "3:1-3:19: paragraph cannot contain paragraph" {
+		t.Errorf("expected the nearest position, got %q", got)
+	}
+}
+
+func TestValidateRegisteredRules(t *testing.T) {
+	RegisterRule(nodeMention, func(node Node) error {
+		if node.(*mention).User == "" {
+			return errors.New("mention without a user")
+		}
+		return nil
+	})
+
+	doc := cloneTestDocument()
+	if err := Validate(doc); err != nil {
+		t.Fatalf("expected a valid document, got %v", err)
+	}
+	mention := doc.LastChild().PrevSibling().LastChild().(*mention)
+	mention.User = ""
+	mention.AddChild(NewCodeBlock(true))
+	err := Validate(doc)
+	for _, message := range []string{"mention without a user", "mention cannot contain codeblock"} {
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("expected %q, got %v", message, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ast
+
+import "iter"
+
+// WalkStatus tells Walk how to continue after visiting a node.
+type WalkStatus int
+
+const (
+	// WalkContinue descends into the node's children, if entering.
+	WalkContinue WalkStatus = iota
+	// WalkSkipChildren skips the node's children. The node is still exited.
+	WalkSkipChildren
+	// WalkStop ends the walk without an error.
+	WalkStop
+)
+
+// WalkFunc is called by Walk when entering a node and again when leaving it.
+type WalkFunc func(n Node, entering bool) (WalkStatus, error)
+
+// Walk traverses the tree rooted at node depth first, calling fn before and
+// after each node's children. It returns the first error from fn. The next
+// sibling is looked up before a node is walked, so fn may remove the node it
+// is given from the tree.
+func Walk(node Node, fn WalkFunc) error {
+	_, err := walk(node, fn)
+	return err
+}
+
+func walk(node Node, fn WalkFunc) (WalkStatus, error) {
+	status, err := fn(node, true)
+	if err != nil || status == WalkStop {
+		return WalkStop, err
+	}
+
+	if status != WalkSkipChildren {
+		for child := node.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			if status, err := walk(child, fn); err != nil || status == WalkStop {
+				return WalkStop, err
+			}
+			child = next
+		}
+	}
+
+	status, err = fn(node, false)
+	if err != nil || status == WalkStop {
+		return WalkStop, err
+	}
+	return WalkContinue, nil
+}
+
+// All yields node and every node below it in document order.
+func All(node Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		all(node, yield)
+	}
+}
+
+func all(node Node, yield func(Node) bool) bool {
+	if !yield(node) {
+		return false
+	}
+	for child := node.FirstChild(); child != nil; {
+		next := child.NextSibling()
+		if !all(child, yield) {
+			return false
+		}
+		child = next
+	}
+	return true
+}
+
+// Descendants yields every node below node in document order.
+func Descendants(node Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for child := node.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			if !all(child, yield) {
+				return
+			}
+			child = next
+		}
+	}
+}
+
+// Ancestors yields the parents of node, nearest first.
+func Ancestors(node Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+			if !yield(parent) {
+				return
+			}
+		}
+	}
+}
+
+// OfType yields the nodes in seq that are a T, typically used with All:
+//
+//	for heading := range ast.OfType[*ast.Heading](ast.All(doc)) {
+//		...
+//	}
+func OfType[T Node](seq iter.Seq[Node]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for node := range seq {
+			if t, ok := node.(T); ok && !yield(t) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package ast
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// walkTestDocument builds:
+//
+//	document
+//	  heading
+//	    text "Title"
+//	  paragraph
+//	    text "a "
+//	    emphasis
+//	      text "b"
+func walkTestDocument() *Document {
+	heading := NewHeading(1)
+	heading.AddChild(NewContent("Title"))
+
+	emphasis := NewEmphasis()
+	emphasis.AddChild(NewContent("b"))
+	paragraph := NewParagraph()
+	paragraph.AddChild(NewContent("a "))
+	paragraph.AddChild(emphasis)
+
+	doc := NewDocument()
+	doc.AddChild(heading)
+	doc.AddChild(paragraph)
+	return doc
+}
+
+func typeNames(nodes []Node) string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Type().String()
+	}
+	return strings.Join(names, " ")
+}
+
+func TestWalkEnterExit(t *testing.T) {
+	var events []string
+	err := Walk(walkTestDocument(), func(n Node, entering bool) (WalkStatus, error) {
+		if entering {
+			events = append(events, "+"+n.Type().String())
+		} else {
+			events = append(events, "-"+n.Type().String())
+		}
+		return WalkContinue, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "+document +heading +text -text -heading +paragraph +text -text +emphasis +text -text -emphasis -paragraph -document"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("unexpected events\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	var entered []Node
+	Walk(walkTestDocument(), func(n Node, entering bool) (WalkStatus, error) {
+		if !entering {
+			return WalkContinue, nil
+		}
+		entered = append(entered, n)
+		if n.Type() == NodeHeading {
+			return WalkSkipChildren, nil
+		}
+		return WalkContinue, nil
+	})
+
+	if got := typeNames(entered); got != "document heading paragraph text emphasis text" {
+		t.Errorf("unexpected nodes %s", got)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	var entered []Node
+	err := Walk(walkTestDocument(), func(n Node, entering bool) (WalkStatus, error) {
+		if !entering {
+			return WalkContinue, nil
+		}
+		entered = append(entered, n)
+		if n.Type() == NodeEmphasis {
+			return WalkStop, nil
+		}
+		return WalkContinue, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := typeNames(entered); got != "document heading text paragraph text emphasis" {
+		t.Errorf("unexpected nodes %s", got)
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	errFound := errors.New("found")
+	calls := 0
+	err := Walk(walkTestDocument(), func(n Node, entering bool) (WalkStatus, error) {
+		calls++
+		if !entering && n.Type() == NodeHeading {
+			return WalkContinue, errFound
+		}
+		return WalkContinue, nil
+	})
+
+	if !errors.Is(err, errFound) {
+		t.Errorf("expected errFound, got %v", err)
+	}
+	if calls != 5 {
+		t.Errorf("expected walk to end at the heading exit, got %d calls", calls)
+	}
+}
+
+func TestWalkRemovingNodes(t *testing.T) {
+	doc := walkTestDocument()
+	Walk(doc, func(n Node, entering bool) (WalkStatus, error) {
+		if entering && n.Type() == NodeContent {
+			n.Parent().RemoveChild(n)
+		}
+		return WalkContinue, nil
+	})
+
+	if got := typeNames(slices.Collect(All(doc))); got != "document heading paragraph emphasis" {
+		t.Errorf("unexpected nodes %s", got)
+	}
+}
+
+func TestAllAndDescendants(t *testing.T) {
+	doc := walkTestDocument()
+
+	if got := typeNames(slices.Collect(All(doc))); got != "document heading text paragraph text emphasis text" {
+		t.Errorf("unexpected All %s", got)
+	}
+	if got := typeNames(slices.Collect(Descendants(doc))); got != "heading text paragraph text emphasis text" {
+		t.Errorf("unexpected Descendants %s", got)
+	}
+
+	count := 0
+	for range All(doc) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected to stop after 3 nodes, got %d", count)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	doc := walkTestDocument()
+	emphasis := doc.LastChild().LastChild()
+	text := emphasis.FirstChild()
+
+	ancestors := slices.Collect(Ancestors(text))
+	if got := typeNames(ancestors); got != "emphasis paragraph document" {
+		t.Errorf("unexpected Ancestors %s", got)
+	}
+	if _, ok := ancestors[1].(*Paragraph); !ok {
+		t.Errorf("expected the concrete *Paragraph, got %T", ancestors[1])
+	}
+	if ancestors[2] != Node(doc) {
+		t.Error("expected the document as the last ancestor")
+	}
+}
+
+func TestOfType(t *testing.T) {
+	doc := walkTestDocument()
+
+	var literals []string
+	for content := range OfType[*Content](All(doc)) {
+		literals = append(literals, content.Literal)
+	}
+	if got := strings.Join(literals, "|"); got != "Title|a |b" {
+		t.Errorf("unexpected literals %s", got)
+	}
+
+	headings := slices.Collect(OfType[*Heading](All(doc)))
+	if len(headings) != 1 || headings[0].Level != 1 {
+		t.Errorf("unexpected headings %v", headings)
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffCommonMark bool
+	diffFormat     string
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff old.md new.md",
+	Short: "Show the semantic differences between two markdown files",
+	Long: "Parse two markdown files and print the blocks and inlines that were inserted, deleted, changed or moved, " +
+		"ignoring changes to formatting alone. With --format html, the new file is rendered as HTML with the changes " +
+		"marked up in <ins> and <del> elements instead. The command exits with status 1 if the files differ.",
+	Args: cobra.ExactArgs(2),
+	Run:  runDiff,
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffCommonMark, "commonmark", false, "parse strict CommonMark without extensions")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "output format: text or html")
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	var opts []parser.Option
+	if diffCommonMark {
+		opts = append(opts, parser.WithCommonMark())
+	}
+	p := parser.New(opts...)
+	old := p.Parse(readInput(args[:1]))
+	new := p.Parse(readInput(args[1:]))
+
+	edits := ast.Diff(old, new)
+	switch diffFormat {
+	case "html":
+		fmt.Print(renderer.RenderHTML(new, renderer.WithRedline(old)))
+	case "text":
+		r := renderer.NewMarkdownRenderer()
+		for i, edit := range edits {
+			if i > 0 {
+				fmt.Println()
+			}
+			printEdit(r, edit)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q: expected text or html\n", diffFormat)
+		os.Exit(1)
+	}
+	if len(edits) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printEdit prints a header naming the kind of edit, the node type and its
+// positions, followed by the old node prefixed with "-" and the new one with
+// "+". Moved nodes are printed once, unprefixed.
+func printEdit(r *renderer.MarkdownRenderer, edit ast.Edit) {
+	node := edit.New
+	if node == nil {
+		node = edit.Old
+	}
+
+	var where string
+	switch edit.Kind {
+	case ast.Inserted:
+		where = edit.New.Pos().String()
+	case ast.Deleted:
+		where = edit.Old.Pos().String()
+	default:
+		where = edit.Old.Pos().String() + " -> " + edit.New.Pos().String()
+	}
+	fmt.Printf("%s %s %s\n", edit.Kind, node.Type(), where)
+
+	switch edit.Kind {
+	case ast.Moved:
+		printLines(r.RenderNode(edit.New), "  ")
+	default:
+		if edit.Old != nil {
+			printLines(r.RenderNode(edit.Old), "- ")
+		}
+		if edit.New != nil {
+			printLines(r.RenderNode(edit.New), "+ ")
+		}
+	}
+}
+
+func printLines(s, prefix string) {
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Println(strings.TrimRight(prefix+line, " "))
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fmtWrite      bool
+	fmtCheck      bool
+	fmtCommonMark bool
+	fmtBullet     string
+	fmtEmphasis   string
+	fmtFence      string
+	fmtSetext     bool
+	fmtWidth      int
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [file...]",
+	Short: "Format markdown as canonical CommonMark",
+	Long: "Parse markdown from files or stdin and print it back in a canonical style. " +
+		"With --write, files are rewritten in place; with --check, the names of files that are not " +
+		"formatted are printed and the command exits with status 1.",
+	Run: runFmt,
+}
+
+func init() {
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "rewrite files in place instead of printing them")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list files that are not formatted and exit with status 1 if any")
+	fmtCmd.Flags().BoolVar(&fmtCommonMark, "commonmark", false, "parse strict CommonMark without extensions")
+	fmtCmd.Flags().StringVar(&fmtBullet, "bullet", "-", "bullet list marker: -, * or +")
+	fmtCmd.Flags().StringVar(&fmtEmphasis, "emphasis", "*", "emphasis delimiter: * or _")
+	fmtCmd.Flags().StringVar(&fmtFence, "fence", "`", "code fence character: ` or ~")
+	fmtCmd.Flags().BoolVar(&fmtSetext, "setext", false, "underline level 1 and 2 headings instead of using #")
+	fmtCmd.Flags().IntVar(&fmtWidth, "width", 0, "reflow paragraphs to this many columns (0 keeps line breaks)")
+	fmtCmd.MarkFlagsMutuallyExclusive("write", "check")
+}
+
+func runFmt(cmd *cobra.Command, args []string) {
+	opts, err := markdownOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	var parserOpts []parser.Option
+	if fmtCommonMark {
+		parserOpts = append(parserOpts, parser.WithCommonMark())
+	}
+	p := parser.New(parserOpts...)
+	format := func(input string) string {
+		return renderer.RenderMarkdown(p.Parse(input), opts...)
+	}
+
+	if len(args) == 0 {
+		if fmtWrite {
+			fmt.Fprintln(os.Stderr, "Error: --write needs file arguments")
+			os.Exit(1)
+		}
+		input := readInput(nil)
+		output := format(input)
+		if fmtCheck {
+			if output != input {
+				fmt.Println("<stdin>")
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Print(output)
+		return
+	}
+
+	unformatted := false
+	for _, path := range args {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+		input := string(content)
+		output := format(input)
+
+		switch {
+		case fmtCheck:
+			if output != input {
+				fmt.Println(path)
+				unformatted = true
+			}
+		case fmtWrite:
+			if output != input {
+				if err := writeFile(path, output); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		default:
+			fmt.Print(output)
+		}
+	}
+	if unformatted {
+		os.Exit(1)
+	}
+}
+
+// markdownOptions turns the style flags into renderer options.
+func markdownOptions() ([]renderer.MarkdownOption, error) {
+	bullet, err := flagChar("bullet", fmtBullet, "-*+")
+	if err != nil {
+		return nil, err
+	}
+	emphasis, err := flagChar("emphasis", fmtEmphasis, "*_")
+	if err != nil {
+		return nil, err
+	}
+	fence, err := flagChar("fence", fmtFence, "`~")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []renderer.MarkdownOption{
+		renderer.WithBulletChar(bullet),
+		renderer.WithEmphasisChar(emphasis),
+		renderer.WithFenceChar(fence),
+		renderer.WithLineWidth(fmtWidth),
+	}
+	if fmtSetext {
+		opts = append(opts, renderer.WithSetextHeadings())
+	}
+	return opts, nil
+}
+
+func flagChar(name, value, allowed string) (byte, error) {
+	for i := 0; i < len(allowed); i++ {
+		if value == allowed[i:i+1] {
+			return allowed[i], nil
+		}
+	}
+	return 0, fmt.Errorf("--%s must be one of %q, got %q", name, allowed, value)
+}
+
+// writeFile replaces the contents of path, keeping its permissions.
+func writeFile(path, content string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), info.Mode().Perm())
+}
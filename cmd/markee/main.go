@@ -1,6 +1,6 @@
 package main
 
-import "markee/cmd"
+import "github.com/rybkr/markee/cmd"
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeCommonMark bool
+	mergeOutput     string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge base.md ours.md theirs.md",
+	Short: "Merge two versions of a markdown file block by block",
+	Long: "Merge the changes ours.md and theirs.md made to base.md, comparing blocks rather than lines so that " +
+		"reflowed paragraphs and other formatting changes do not conflict. Blocks changed on both sides are " +
+		"written between conflict markers. The merged file is printed in canonical style, and the command " +
+		"exits with status 1 if there are conflicts.",
+	Args: cobra.ExactArgs(3),
+	Run:  runMerge,
+}
+
+func init() {
+	mergeCmd.Flags().BoolVar(&mergeCommonMark, "commonmark", false, "parse strict CommonMark without extensions")
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "write the merged file here instead of printing it")
+}
+
+func runMerge(cmd *cobra.Command, args []string) {
+	var opts []parser.Option
+	if mergeCommonMark {
+		opts = append(opts, parser.WithCommonMark())
+	}
+	p := parser.New(opts...)
+	base := p.Parse(readInput(args[0:1]))
+	ours := p.Parse(readInput(args[1:2]))
+	theirs := p.Parse(readInput(args[2:3]))
+
+	merged, conflicts := ast.Merge(base, ours, theirs)
+	output := renderer.RenderMarkdown(merged, renderer.WithConflictLabels(args[1], args[2]))
+	if mergeOutput == "" {
+		fmt.Print(output)
+	} else if err := os.WriteFile(mergeOutput, []byte(output), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		os.Exit(1)
+	}
+
+	if conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "Conflicts: %d\n", conflicts)
+		os.Exit(1)
+	}
+}
@@ -1,28 +1,88 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
 	"github.com/spf13/cobra"
-	"markee/internal/ast"
-	"markee/internal/parser"
-	"markee/internal/renderer"
+)
+
+var (
+	parseCommonMark bool
+	parseGFM        bool
+	parsePositions  bool
+	parseSourcePos  bool
+	parseFormat     string
 )
 
 var parseCmd = &cobra.Command{
 	Use:   "parse [file]",
 	Short: "Parse markdown input and display AST",
-	Long:  "Parse markdown from a file or stdin and display the resulting AST structure.",
-	Args:  cobra.MaximumNArgs(1),
-	Run:   runParse,
+	Long: "Parse markdown from a file or stdin and display the resulting AST structure, as an indented tree followed by the HTML, or as JSON. " +
+		"Warnings about suspicious input, such as unclosed code fences or undefined references, are printed to stderr.",
+	Args: cobra.MaximumNArgs(1),
+	Run:  runParse,
+}
+
+func init() {
+	parseCmd.Flags().BoolVar(&parseCommonMark, "commonmark", false, "parse strict CommonMark without extensions")
+	parseCmd.Flags().BoolVar(&parseGFM, "gfm", false, "parse GitHub Flavored Markdown instead of the default extensions")
+	parseCmd.Flags().BoolVar(&parsePositions, "positions", false, "show the source position of each node")
+	parseCmd.Flags().BoolVar(&parseSourcePos, "sourcepos", false, "add data-sourcepos attributes to the HTML")
+	parseCmd.Flags().StringVar(&parseFormat, "format", "tree", "output format: tree or json")
 }
 
 func runParse(cmd *cobra.Command, args []string) {
-	var input string
+	input := readInput(args)
 
+	var opts []parser.Option
+	if parseCommonMark {
+		opts = append(opts, parser.WithCommonMark())
+	}
+	if parseGFM {
+		opts = append(opts, parser.WithGFM())
+	}
+	doc, diagnostics := parser.New(opts...).ParseWithDiagnostics(input)
+	for _, d := range diagnostics {
+		if len(args) == 1 {
+			fmt.Fprintf(os.Stderr, "%s:", args[0])
+		}
+		fmt.Fprintln(os.Stderr, d)
+	}
+	switch parseFormat {
+	case "json":
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding AST: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	case "tree":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q: expected tree or json\n", parseFormat)
+		os.Exit(1)
+	}
+
+    printTree(doc, 0)
+    fmt.Print("\n\n")
+    var htmlOpts []renderer.HTMLOption
+    if parseSourcePos {
+        htmlOpts = append(htmlOpts, renderer.WithSourcePositions())
+    }
+    html := renderer.RenderHTML(doc, htmlOpts...)
+    fmt.Println(html)
+}
+
+// readInput returns the contents of the file named by args, or of stdin when
+// no file is given. It exits the process if the input cannot be read.
+func readInput(args []string) string {
 	if len(args) == 1 {
 		// Read from file
 		content, err := os.ReadFile(args[0])
@@ -30,75 +90,84 @@ func runParse(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 			os.Exit(1)
 		}
-		input = string(content)
-	} else {
-		// Read from stdin
-		content, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
-			os.Exit(1)
-		}
-		input = string(content)
+		return string(content)
 	}
 
-	doc := parser.Parse(input)
-    printTree(doc, 0)
-    fmt.Println("\n")
-    html := renderer.RenderHTML(doc)
-    fmt.Println(html)
+	// Read from stdin
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		os.Exit(1)
+	}
+	return string(content)
 }
 
 func printTree(node ast.Node, depth int) {
 	indent := strings.Repeat("  ", depth)
 
+	var label string
 	switch n := node.(type) {
 	case *ast.Document:
-		fmt.Printf("%s[Document]\n", indent)
+		label = "[Document]"
 	case *ast.Heading:
-		fmt.Printf("%s[Heading level=%d]\n", indent, n.Level)
+		label = fmt.Sprintf("[Heading level=%d]", n.Level)
 	case *ast.Paragraph:
-		fmt.Printf("%s[Paragraph]\n", indent)
+		label = "[Paragraph]"
 	case *ast.BlockQuote:
-		fmt.Printf("%s[BlockQuote]\n", indent)
+		label = "[BlockQuote]"
 	case *ast.CodeBlock:
 		ftype := "indented"
 		if n.IsFenced {
 			ftype = "fenced"
 		}
-		fmt.Printf("%s[CodeBlock %s info=%q]\n", indent, ftype, n.Language)
+		label = fmt.Sprintf("[CodeBlock %s info=%q]", ftype, n.Language)
 	case *ast.ThematicBreak:
-		fmt.Printf("%s[ThematicBreak]\n", indent)
+		label = "[ThematicBreak]"
+	case *ast.TOC:
+		label = "[TOC]"
+	case *ast.AbbreviationDefinition:
+		label = fmt.Sprintf("[AbbreviationDefinition label=%q title=%q]", n.Label, n.Title)
 	case *ast.List:
 		ltype := "unordered"
 		if n.IsOrdered {
 			ltype = "ordered"
 		}
-		fmt.Printf("%s[List %s tight=%v]\n", indent, ltype, n.IsTight)
+		label = fmt.Sprintf("[List %s tight=%v]", ltype, n.IsTight)
 	case *ast.ListItem:
-		fmt.Printf("%s[ListItem]\n", indent)
+		label = "[ListItem]"
+	case *ast.FencedDiv:
+		label = fmt.Sprintf("[FencedDiv name=%q]", n.Name)
 	case *ast.Content:
-		fmt.Printf("%s[Text] %q\n", indent, n.Literal)
+		label = fmt.Sprintf("[Text] %q", n.Literal)
 	case *ast.Emphasis:
-		fmt.Printf("%s[Emphasis]\n", indent)
+		label = "[Emphasis]"
 	case *ast.Strong:
-		fmt.Printf("%s[Strong]\n", indent)
+		label = "[Strong]"
+	case *ast.Strikethrough:
+		label = "[Strikethrough]"
 	case *ast.CodeSpan:
-		fmt.Printf("%s[Code] %q\n", indent, n.Literal)
+		label = fmt.Sprintf("[Code] %q", n.Literal)
 	case *ast.Link:
-		fmt.Printf("%s[Link dest=%q title=%q]\n", indent, n.Destination, n.Title)
+		label = fmt.Sprintf("[Link dest=%q title=%q]", n.Destination, n.Title)
 	case *ast.Image:
-		fmt.Printf("%s[Image dest=%q title=%q alt=%q]\n", indent, n.Destination, n.Title, n.AltText)
+		label = fmt.Sprintf("[Image dest=%q title=%q alt=%q]", n.Destination, n.Title, n.AltText)
+	case *ast.Abbreviation:
+		label = fmt.Sprintf("[Abbreviation title=%q]", n.Title)
 	case *ast.LineBreak:
-		fmt.Printf("%s[LineBreak]\n", indent)
+		label = "[LineBreak]"
 	case *ast.SoftBreak:
-		fmt.Printf("%s[SoftBreak]\n", indent)
+		label = "[SoftBreak]"
 	case *ast.HTMLBlock:
-		fmt.Printf("%s[HTMLBlock] %q\n", indent, n.Literal)
+		label = fmt.Sprintf("[HTMLBlock] %q", n.Literal)
 	case *ast.HTMLSpan:
-		fmt.Printf("%s[HTMLInline] %q\n", indent, n.Literal)
+		label = fmt.Sprintf("[HTMLInline] %q", n.Literal)
 	default:
-		fmt.Printf("%s[Unknown: %T]\n", indent, node)
+		label = fmt.Sprintf("[%s]", node.Type())
+	}
+	if parsePositions {
+		label += " @" + node.Pos().String()
 	}
+	fmt.Printf("%s%s\n", indent, label)
 
 	for _, child := range node.Children() {
 		printTree(child, depth+1)
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryCommonMark bool
+	queryGFM        bool
+	queryFormat     string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query selector [file...]",
+	Short: "Find the nodes of markdown files that match a selector",
+	Long: "Parse markdown files, or stdin, and print the nodes that match a CSS-like selector such as " +
+		"'heading[level=2] + paragraph' or 'list > listitem link[destination^=\"http\"]'. Each match is printed " +
+		"with its file, position and type and the first line of its markdown, or as JSON with --format json. " +
+		"The command exits with status 1 if nothing matches.",
+	Args: cobra.MinimumNArgs(1),
+	Run:  runQuery,
+}
+
+func init() {
+	queryCmd.Flags().BoolVar(&queryCommonMark, "commonmark", false, "parse strict CommonMark without extensions")
+	queryCmd.Flags().BoolVar(&queryGFM, "gfm", false, "parse GitHub Flavored Markdown instead of the default extensions")
+	queryCmd.Flags().StringVar(&queryFormat, "format", "text", "output format: text or json")
+}
+
+// queryMatch is a match as printed with --format json.
+type queryMatch struct {
+	File string          `json:"file,omitempty"`
+	Node json.RawMessage `json:"node"`
+}
+
+func runQuery(cmd *cobra.Command, args []string) {
+	selector, err := ast.CompileSelector(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if queryFormat != "text" && queryFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q: expected text or json\n", queryFormat)
+		os.Exit(1)
+	}
+
+	var opts []parser.Option
+	if queryCommonMark {
+		opts = append(opts, parser.WithCommonMark())
+	}
+	if queryGFM {
+		opts = append(opts, parser.WithGFM())
+	}
+	p := parser.New(opts...)
+
+	files := args[1:]
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	r := renderer.NewMarkdownRenderer()
+	jsonMatches := []queryMatch{}
+	found := false
+	for _, file := range files {
+		var input string
+		if file == "" {
+			input = readInput(nil)
+		} else {
+			input = readInput([]string{file})
+		}
+
+		for _, node := range selector.Select(p.Parse(input)) {
+			found = true
+			if queryFormat == "json" {
+				encoded, err := ast.MarshalJSON(node)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error encoding node: %v\n", err)
+					os.Exit(1)
+				}
+				jsonMatches = append(jsonMatches, queryMatch{File: file, Node: encoded})
+				continue
+			}
+
+			where := node.Pos().String()
+			if file != "" {
+				where = file + ":" + where
+			}
+			preview, _, _ := strings.Cut(r.RenderNode(node), "\n")
+			fmt.Printf("%s %s %s\n", where, node.Type(), preview)
+		}
+	}
+
+	if queryFormat == "json" {
+		out, err := json.MarshalIndent(jsonMatches, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding matches: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	}
+	if !found {
+		os.Exit(1)
+	}
+}
@@ -22,4 +22,9 @@ func Execute() {
 
 func init() {
 	rootCmd.AddCommand(parseCmd)
+	rootCmd.AddCommand(tocCmd)
+	rootCmd.AddCommand(fmtCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(queryCmd)
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/toc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tocMinLevel int
+	tocMaxLevel int
+)
+
+var tocCmd = &cobra.Command{
+	Use:   "toc [file]",
+	Short: "Print the table of contents as JSON",
+	Long:  "Parse markdown from a file or stdin and print its heading outline (level, text and anchor id) as JSON.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runTOC,
+}
+
+func init() {
+	tocCmd.Flags().IntVar(&tocMinLevel, "min-level", 1, "lowest heading level to include")
+	tocCmd.Flags().IntVar(&tocMaxLevel, "max-level", 6, "highest heading level to include")
+}
+
+func runTOC(cmd *cobra.Command, args []string) {
+	input := readInput(args)
+
+	doc := parser.Parse(input)
+	entries := toc.Build(doc, toc.Options{MinLevel: tocMinLevel, MaxLevel: tocMaxLevel})
+	if entries == nil {
+		entries = []*toc.Entry{}
+	}
+
+	out, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding outline: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
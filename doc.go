@@ -0,0 +1,32 @@
+// Package markee parses CommonMark documents and renders them as HTML.
+//
+// The simplest use is a single call:
+//
+//	html := markee.Convert("# Hello, *world*")
+//
+// A Markdown value bundles options and extensions for repeated use:
+//
+//	md := markee.New(markee.WithHTMLOptions(
+//		renderer.WithHeadingIDs(),
+//		renderer.WithSyntaxHighlighting(),
+//	))
+//	doc := md.Parse(source)
+//	html := md.Render(doc)
+//
+// Syntax beyond CommonMark is provided by parser extensions, which can be
+// switched off or added to:
+//
+//	strict := markee.New(markee.WithParserOptions(parser.WithCommonMark()))
+//
+// Parse returns an *ast.Document that can be inspected, transformed or
+// rendered by a custom ast.Visitor before being passed to Render.
+//
+// # Compatibility
+//
+// The packages markee, ast, parser, renderer, toc and highlight form the
+// public API and follow semantic versioning: exported identifiers are not
+// removed or changed incompatibly within a major version. New node types,
+// Visitor methods and options may be added in minor versions, so custom
+// visitors should embed ast.BaseVisitor rather than implement ast.Visitor
+// from scratch. The cmd package may change at any time.
+package markee
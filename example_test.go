@@ -0,0 +1,51 @@
+package markee_test
+
+import (
+	"fmt"
+
+	"github.com/rybkr/markee"
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+func ExampleConvert() {
+	fmt.Print(markee.Convert("# Hello\n\nSome `code`."))
+	// Output:
+	// <h1>Hello</h1>
+	// <p>Some <code>code</code>.</p>
+}
+
+func ExampleNew() {
+	md := markee.New(markee.WithHTMLOptions(renderer.WithHeadingIDs()))
+	fmt.Print(md.Convert("## Getting started"))
+	// Output:
+	// <h2 id="getting-started">Getting started</h2>
+}
+
+// noteBox renders ::: note containers as an <aside>.
+type noteBox struct{}
+
+func (noteBox) Extend(m *markee.Markdown) {
+	m.AddHTMLOptions(renderer.WithDivRenderer("note", func(r *renderer.HTMLRenderer, div *ast.FencedDiv) {
+		r.WriteString("<aside>\n")
+		r.RenderChildren(div)
+		r.WriteString("</aside>\n")
+	}))
+}
+
+func ExampleWithExtensions() {
+	md := markee.New(markee.WithExtensions(noteBox{}))
+	fmt.Print(md.Convert("::: note\nRemember this.\n:::"))
+	// Output:
+	// <aside>
+	// <p>Remember this.</p>
+	// </aside>
+}
+
+func ExampleWithParserOptions() {
+	md := markee.New(markee.WithParserOptions(parser.WithCommonMark()))
+	fmt.Print(md.Convert("[TOC]"))
+	// Output:
+	// <p>[TOC]</p>
+}
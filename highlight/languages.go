@@ -0,0 +1,219 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	register(goLexer, "go", "golang")
+	register(jsonLexer, "json")
+	register(yamlLexer{}, "yaml", "yml")
+	register(shellLexer, "sh", "bash", "shell", "zsh", "console")
+	register(pythonLexer, "python", "py")
+	register(javascriptLexer, "javascript", "js", "jsx", "mjs")
+	register(sqlLexer, "sql")
+	register(diffLexer{}, "diff", "patch")
+}
+
+var goLexer = &codeLexer{
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        `"'`,
+	rawQuotes:     "`",
+	keywords: newWords(`break case chan const continue default defer else fallthrough
+		for func go goto if import interface map package range return select struct
+		switch type var`),
+	types: newWords(`any bool byte comparable complex64 complex128 error float32 float64
+		int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr`),
+	builtins: newWords(`append cap clear close complex copy delete imag len make max min
+		new panic print println real recover`),
+	literals: newWords(`true false nil iota`),
+}
+
+var jsonLexer = &codeLexer{
+	quotes:   `"`,
+	keys:     true,
+	literals: newWords(`true false null`),
+}
+
+var shellLexer = &codeLexer{
+	lineComments: []string{"#"},
+	wordComments: true,
+	quotes:       `"`,
+	rawQuotes:    `'`,
+	identChars:   "-",
+	variables:    true,
+	keywords: newWords(`if then else elif fi for while until do done case esac in
+		function select return break continue local export readonly declare unset
+		shift exit`),
+	builtins: newWords(`alias cd echo eval exec printf pwd read set source test trap`),
+}
+
+var pythonLexer = &codeLexer{
+	lineComments: []string{"#"},
+	quotes:       `"'`,
+	tripleQuotes: true,
+	keywords: newWords(`and as assert async await break class continue def del elif
+		else except finally for from global if import in is lambda nonlocal not or
+		pass raise return try while with yield match case`),
+	builtins: newWords(`abs all any bool bytes dict enumerate filter float format
+		getattr hasattr int isinstance len list map max min object open print range
+		repr reversed set sorted str sum super tuple type zip`),
+	literals: newWords(`True False None`),
+}
+
+var javascriptLexer = &codeLexer{
+	lineComments:  []string{"//"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        "\"'`",
+	identStart:    "$",
+	identChars:    "$",
+	keywords: newWords(`async await break case catch class const continue debugger
+		default delete do else export extends finally for function if import in
+		instanceof let new of return static super switch this throw try typeof var
+		void while with yield`),
+	builtins: newWords(`Array Boolean console Date document Error JSON Map Math Number
+		Object Promise RegExp require Set String Symbol window`),
+	literals: newWords(`true false null undefined NaN Infinity`),
+}
+
+var sqlLexer = &codeLexer{
+	lineComments:  []string{"--"},
+	blockComments: [][2]string{{"/*", "*/"}},
+	quotes:        `'"`,
+	ignoreCase:    true,
+	keywords: newWords(`add all alter and as asc begin between by case check commit
+		constraint create default delete desc distinct drop else end exists foreign
+		from full group having if in index inner insert into is join key left like
+		limit not offset on or order outer primary references returning right
+		rollback select set table then transaction union unique update values view
+		when where with`),
+	types: newWords(`bigint bool boolean char date decimal double float int integer
+		json jsonb numeric real serial smallint text timestamp timestamptz uuid
+		varchar`),
+	builtins: newWords(`avg coalesce count length lower max min now sum upper`),
+	literals: newWords(`true false null`),
+}
+
+// yamlLexer highlights YAML line by line: mapping keys, comments, document
+// markers, list dashes and scalar values.
+type yamlLexer struct{}
+
+// ^([ \t]*)                             : indentation
+// ((?:- +)*)                            : list item markers
+// ([^\s#'"\-][^#]*?|"[^"]*"|'[^']*')    : mapping key, plain or quoted
+// (:)(?:[ \t]|$)                        : separator, then a space or end of line
+var reYAMLKey = regexp.MustCompile(`^([ \t]*)((?:- +)*)([^\s#'"\-][^#]*?|"[^"]*"|'[^']*')(:)(?:[ \t]|$)`)
+
+var yamlLiterals = newWords(`true false yes no on off null ~ True False Yes No On Off Null TRUE FALSE NULL`)
+
+func (yamlLexer) Tokenize(source string) []Token {
+	var tokens tokenList
+	lines := strings.SplitAfter(source, "\n")
+	for _, line := range lines {
+		body := strings.TrimSuffix(line, "\n")
+		trimmed := strings.TrimSpace(body)
+
+		switch {
+		case trimmed == "---" || trimmed == "...":
+			tokens.add(Meta, body)
+		case strings.HasPrefix(trimmed, "#"):
+			tokens.add(Text, body[:len(body)-len(strings.TrimLeft(body, " \t"))])
+			tokens.add(Comment, strings.TrimLeft(body, " \t"))
+		default:
+			rest := body
+			if m := reYAMLKey.FindStringSubmatch(body); m != nil {
+				tokens.add(Text, m[1])
+				tokens.add(Punctuation, m[2])
+				tokens.add(Key, m[3])
+				tokens.add(Punctuation, m[4])
+				rest = body[len(m[1])+len(m[2])+len(m[3])+len(m[4]):]
+			} else {
+				indent := len(body) - len(strings.TrimLeft(body, " \t"))
+				tokens.add(Text, body[:indent])
+				rest = body[indent:]
+				for strings.HasPrefix(rest, "- ") || rest == "-" {
+					n := len(rest) - len(strings.TrimLeft(rest[1:], " "))
+					tokens.add(Punctuation, rest[:n])
+					rest = rest[n:]
+				}
+			}
+			yamlValue(&tokens, rest)
+		}
+
+		if strings.HasSuffix(line, "\n") {
+			tokens.add(Text, "\n")
+		}
+	}
+	return tokens
+}
+
+// yamlValue highlights the scalar after a key or list marker, together with
+// any trailing comment.
+func yamlValue(tokens *tokenList, s string) {
+	value := s
+	comment := ""
+	if i := strings.Index(s, " #"); i >= 0 && !strings.ContainsAny(s[:i], `"'`) {
+		value, comment = s[:i], s[i:]
+	}
+
+	trimmed := strings.TrimSpace(value)
+	lead := value[:len(value)-len(strings.TrimLeft(value, " \t"))]
+	trail := value[len(lead)+len(trimmed):]
+
+	tokens.add(Text, lead)
+	switch {
+	case trimmed == "":
+	case yamlLiterals[trimmed]:
+		tokens.add(Literal, trimmed)
+	case isYAMLNumber(trimmed):
+		tokens.add(Number, trimmed)
+	case strings.HasPrefix(trimmed, "&") || strings.HasPrefix(trimmed, "*"):
+		tokens.add(Variable, trimmed)
+	case trimmed == "|" || trimmed == ">" || trimmed == "|-" || trimmed == ">-":
+		tokens.add(Operator, trimmed)
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		tokens.add(Text, trimmed)
+	default:
+		tokens.add(String, trimmed)
+	}
+	tokens.add(Text, trail)
+
+	if comment != "" {
+		tokens.add(Text, " ")
+		tokens.add(Comment, comment[1:])
+	}
+}
+
+var reYAMLNumber = regexp.MustCompile(`^[-+]?(?:\d[\d_]*(?:\.\d*)?(?:[eE][-+]?\d+)?|0x[0-9a-fA-F]+|\.inf|\.nan)$`)
+
+func isYAMLNumber(s string) bool {
+	return reYAMLNumber.MatchString(s)
+}
+
+// diffLexer highlights unified diffs line by line.
+type diffLexer struct{}
+
+func (diffLexer) Tokenize(source string) []Token {
+	var tokens tokenList
+	for _, line := range strings.SplitAfter(source, "\n") {
+		body := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(body, "+++ "), strings.HasPrefix(body, "--- "),
+			strings.HasPrefix(body, "diff "), strings.HasPrefix(body, "index "),
+			strings.HasPrefix(body, "@@"):
+			tokens.add(Meta, body)
+		case strings.HasPrefix(body, "+"):
+			tokens.add(Inserted, body)
+		case strings.HasPrefix(body, "-"):
+			tokens.add(Deleted, body)
+		default:
+			tokens.add(Text, body)
+		}
+		if strings.HasSuffix(line, "\n") {
+			tokens.add(Text, "\n")
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,292 @@
+// Package highlight tokenizes source code for syntax highlighting. Lexers
+// are looked up by code block language and themes map token types to CSS.
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lexer splits source code into tokens. Concatenating the values of the
+// returned tokens always gives back the source.
+type Lexer interface {
+	Tokenize(source string) []Token
+}
+
+var lexers = make(map[string]Lexer)
+
+func register(lexer Lexer, names ...string) {
+	for _, name := range names {
+		lexers[name] = lexer
+	}
+}
+
+// Lookup returns the lexer for a code block language such as "go" or "bash".
+func Lookup(language string) (Lexer, bool) {
+	lexer, ok := lexers[strings.ToLower(language)]
+	return lexer, ok
+}
+
+// Tokenize highlights source as language. Unknown languages yield a single
+// Text token.
+func Tokenize(language, source string) []Token {
+	if lexer, ok := Lookup(language); ok {
+		return lexer.Tokenize(source)
+	}
+	if source == "" {
+		return nil
+	}
+	return []Token{{Type: Text, Value: source}}
+}
+
+// codeLexer is a configurable scanner for languages made of comments,
+// quoted strings, numbers, words and operators.
+type codeLexer struct {
+	lineComments  []string
+	blockComments [][2]string
+	// wordComments only starts line comments at the beginning of a word, as
+	// in shell where foo#bar is a single word.
+	wordComments bool
+
+	quotes       string // open strings with backslash escapes
+	rawQuotes    string // open strings without escapes
+	tripleQuotes bool   // python """ and ''' strings
+
+	identStart string // characters that may start a word besides letters and _
+	identChars string // characters allowed in words besides letters, digits and _
+	variables  bool   // shell $NAME and ${NAME}
+	keys       bool   // strings followed by ':' are keys, as in JSON
+	ignoreCase bool
+
+	keywords words
+	types    words
+	builtins words
+	literals words
+}
+
+type words map[string]bool
+
+func newWords(list string) words {
+	w := make(words)
+	for _, word := range strings.Fields(list) {
+		w[word] = true
+	}
+	return w
+}
+
+const (
+	operatorChars    = "+-*/%=<>!&|^~?:@"
+	punctuationChars = "()[]{},;."
+)
+
+func (l *codeLexer) Tokenize(source string) []Token {
+	var tokens tokenList
+
+	for pos := 0; pos < len(source); {
+		rest := source[pos:]
+		c := source[pos]
+
+		if end := l.scanComment(source, pos); end > pos {
+			tokens.add(Comment, source[pos:end])
+			pos = end
+			continue
+		}
+
+		if end := l.scanString(rest); end > 0 {
+			t := String
+			if l.keys && followedByColon(rest[end:]) {
+				t = Key
+			}
+			tokens.add(t, rest[:end])
+			pos += end
+			continue
+		}
+
+		if l.variables && c == '$' {
+			end := scanVariable(rest)
+			tokens.add(Variable, rest[:end])
+			pos += end
+			continue
+		}
+
+		if isDigit(c) || (c == '.' && len(rest) > 1 && isDigit(rest[1])) {
+			end := scanNumber(rest)
+			tokens.add(Number, rest[:end])
+			pos += end
+			continue
+		}
+
+		if l.startsWord(rest) {
+			end := l.scanWord(rest)
+			word := rest[:end]
+			tokens.add(l.classify(word), word)
+			pos += end
+			continue
+		}
+
+		switch {
+		case strings.IndexByte(operatorChars, c) >= 0:
+			tokens.add(Operator, rest[:1])
+			pos++
+		case strings.IndexByte(punctuationChars, c) >= 0:
+			tokens.add(Punctuation, rest[:1])
+			pos++
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			tokens.add(Text, rest[:size])
+			pos += size
+		}
+	}
+
+	return tokens
+}
+
+func (l *codeLexer) scanComment(source string, pos int) int {
+	rest := source[pos:]
+	for _, prefix := range l.lineComments {
+		if !strings.HasPrefix(rest, prefix) {
+			continue
+		}
+		if l.wordComments && pos > 0 && !isSpace(source[pos-1]) {
+			continue
+		}
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return pos + end
+		}
+		return len(source)
+	}
+	for _, delims := range l.blockComments {
+		if !strings.HasPrefix(rest, delims[0]) {
+			continue
+		}
+		if end := strings.Index(rest[len(delims[0]):], delims[1]); end >= 0 {
+			return pos + len(delims[0]) + end + len(delims[1])
+		}
+		return len(source)
+	}
+	return pos
+}
+
+// scanString returns the length of the string literal at the start of s, or
+// 0 if s does not start with one. Unterminated strings run to the end of the
+// line, or of the input for raw and triple-quoted strings.
+func (l *codeLexer) scanString(s string) int {
+	c := s[0]
+	if l.tripleQuotes && (strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, `'''`)) {
+		if end := strings.Index(s[3:], s[:3]); end >= 0 {
+			return end + 6
+		}
+		return len(s)
+	}
+	if strings.IndexByte(l.rawQuotes, c) >= 0 {
+		if end := strings.IndexByte(s[1:], c); end >= 0 {
+			return end + 2
+		}
+		return len(s)
+	}
+	if strings.IndexByte(l.quotes, c) < 0 {
+		return 0
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i + 1
+		case '\n':
+			if c != '`' {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+func (l *codeLexer) startsWord(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r) || strings.IndexByte(l.identStart, s[0]) >= 0
+}
+
+func (l *codeLexer) scanWord(s string) int {
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) && (size != 1 || strings.IndexByte(l.identChars, s[end]) < 0) {
+			break
+		}
+		end += size
+	}
+	return end
+}
+
+func (l *codeLexer) classify(word string) TokenType {
+	if l.ignoreCase {
+		word = strings.ToLower(word)
+	}
+	switch {
+	case l.keywords[word]:
+		return Keyword
+	case l.literals[word]:
+		return Literal
+	case l.types[word]:
+		return Type
+	case l.builtins[word]:
+		return Builtin
+	}
+	return Text
+}
+
+func scanNumber(s string) int {
+	end := 0
+	for end < len(s) {
+		c := s[end]
+		if isDigit(c) || isLetter(c) || c == '_' || c == '.' {
+			end++
+		} else if (c == '+' || c == '-') && end > 0 && (s[end-1] == 'e' || s[end-1] == 'E') && !strings.HasPrefix(s, "0x") {
+			end++
+		} else {
+			break
+		}
+	}
+	return end
+}
+
+// scanVariable scans a shell parameter expansion: $NAME, ${...} or a
+// special parameter such as $1, $@ or $?.
+func scanVariable(s string) int {
+	if len(s) < 2 {
+		return 1
+	}
+	if s[1] == '{' {
+		if end := strings.IndexByte(s, '}'); end >= 0 {
+			return end + 1
+		}
+		return len(s)
+	}
+	if strings.IndexByte("@*#?$!-0123456789", s[1]) >= 0 {
+		return 2
+	}
+	end := 1
+	for end < len(s) && (isLetter(s[end]) || isDigit(s[end]) || s[end] == '_') {
+		end++
+	}
+	return end
+}
+
+func followedByColon(s string) bool {
+	s = strings.TrimLeft(s, " \t")
+	return strings.HasPrefix(s, ":")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
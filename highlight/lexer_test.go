@@ -0,0 +1,85 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func assertTokens(t *testing.T, tokens []Token, expected []Token) {
+	t.Helper()
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(tokens), tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Errorf("token %d: expected %v, got %v", i, expected[i], tokens[i])
+		}
+	}
+}
+
+func TestTokenizeRoundTrip(t *testing.T) {
+	sources := map[string]string{
+		"go":     "package main\n\n// main\nfunc main() {\n\tfmt.Println(`raw`, \"s\\\"q\", 1.5e-3)\n}\n",
+		"json":   `{"a": [1, true, null], "b": "c"}`,
+		"yaml":   "# c\nkey: value # x\nlist:\n  - 1\n  - name: \"q\"\n---\n",
+		"bash":   "export FOO=${BAR} # set\necho \"$HOME\" 'x' a#b\n",
+		"python": "def f(x):\n    \"\"\"doc\"\"\"\n    return None\n",
+		"js":     "const $x = `t` // c\n",
+		"sql":    "SELECT * FROM t WHERE id = 'x' -- c\n",
+		"diff":   "--- a\n+++ b\n@@ -1 +1 @@\n-old\n+new\n ctx\n",
+	}
+	for language, source := range sources {
+		var b strings.Builder
+		for _, token := range Tokenize(language, source) {
+			b.WriteString(token.Value)
+		}
+		if b.String() != source {
+			t.Errorf("%s: tokens do not reproduce the source\nexpected %q\ngot      %q", language, source, b.String())
+		}
+	}
+}
+
+func TestTokenizeGo(t *testing.T) {
+	tokens := Tokenize("go", `return nil // done`)
+	assertTokens(t, tokens, []Token{
+		{Keyword, "return"},
+		{Text, " "},
+		{Literal, "nil"},
+		{Text, " "},
+		{Comment, "// done"},
+	})
+}
+
+func TestTokenizeJSONKeys(t *testing.T) {
+	tokens := Tokenize("json", `{"a": "b"}`)
+	assertTokens(t, tokens, []Token{
+		{Punctuation, "{"},
+		{Key, `"a"`},
+		{Operator, ":"},
+		{Text, " "},
+		{String, `"b"`},
+		{Punctuation, "}"},
+	})
+}
+
+func TestTokenizeSQLIgnoresCase(t *testing.T) {
+	tokens := Tokenize("sql", "select")
+	assertTokens(t, tokens, []Token{{Keyword, "select"}})
+	tokens = Tokenize("SQL", "SELECT")
+	assertTokens(t, tokens, []Token{{Keyword, "SELECT"}})
+}
+
+func TestTokenizeUnknownLanguage(t *testing.T) {
+	tokens := Tokenize("cobol", "MOVE A TO B")
+	assertTokens(t, tokens, []Token{{Text, "MOVE A TO B"}})
+}
+
+func TestSplitLines(t *testing.T) {
+	lines := SplitLines([]Token{{Comment, "/* a\nb */"}, {Text, "\n"}, {Keyword, "if"}})
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	assertTokens(t, lines[0], []Token{{Comment, "/* a"}})
+	assertTokens(t, lines[1], []Token{{Comment, "b */"}})
+	assertTokens(t, lines[2], []Token{{Keyword, "if"}})
+}
@@ -0,0 +1,69 @@
+package highlight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Theme maps token types to CSS declarations. It is used for inline styles,
+// or to generate a stylesheet for the tok-* classes with CSS.
+type Theme map[TokenType]string
+
+var GitHub = Theme{
+	Keyword:  "color: #d73a49",
+	Type:     "color: #6f42c1",
+	Builtin:  "color: #005cc5",
+	Literal:  "color: #005cc5",
+	Key:      "color: #22863a",
+	Variable: "color: #e36209",
+	String:   "color: #032f62",
+	Number:   "color: #005cc5",
+	Comment:  "color: #6a737d; font-style: italic",
+	Operator: "color: #d73a49",
+	Inserted: "color: #22863a; background-color: #f0fff4",
+	Deleted:  "color: #b31d28; background-color: #ffeef0",
+	Meta:     "color: #6f42c1; font-weight: bold",
+}
+
+var Monokai = Theme{
+	Keyword:  "color: #f92672",
+	Type:     "color: #66d9ef",
+	Builtin:  "color: #66d9ef",
+	Literal:  "color: #ae81ff",
+	Key:      "color: #a6e22e",
+	Variable: "color: #fd971f",
+	String:   "color: #e6db74",
+	Number:   "color: #ae81ff",
+	Comment:  "color: #75715e; font-style: italic",
+	Operator: "color: #f92672",
+	Inserted: "color: #a6e22e",
+	Deleted:  "color: #f92672",
+	Meta:     "color: #75715e; font-weight: bold",
+}
+
+var themes = map[string]Theme{
+	"github":  GitHub,
+	"monokai": Monokai,
+}
+
+// LookupTheme returns a built-in theme by name.
+func LookupTheme(name string) (Theme, bool) {
+	theme, ok := themes[strings.ToLower(name)]
+	return theme, ok
+}
+
+// CSS returns a stylesheet applying the theme to tok-* classes.
+func (t Theme) CSS() string {
+	types := make([]TokenType, 0, len(t))
+	for tokenType := range t {
+		types = append(types, tokenType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	var b strings.Builder
+	for _, tokenType := range types {
+		b.WriteString(fmt.Sprintf(".tok-%s { %s; }\n", tokenType, t[tokenType]))
+	}
+	return b.String()
+}
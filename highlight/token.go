@@ -0,0 +1,90 @@
+package highlight
+
+import "strings"
+
+type TokenType int
+
+const (
+	Text TokenType = iota
+	Keyword
+	Type
+	Builtin
+	Literal
+	Key
+	Variable
+	String
+	Number
+	Comment
+	Operator
+	Punctuation
+	Inserted
+	Deleted
+	Meta
+)
+
+var tokenTypeNames = [...]string{
+	Text:        "text",
+	Keyword:     "keyword",
+	Type:        "type",
+	Builtin:     "builtin",
+	Literal:     "literal",
+	Key:         "key",
+	Variable:    "variable",
+	String:      "string",
+	Number:      "number",
+	Comment:     "comment",
+	Operator:    "operator",
+	Punctuation: "punctuation",
+	Inserted:    "inserted",
+	Deleted:     "deleted",
+	Meta:        "meta",
+}
+
+// String returns the name used for the token's CSS class, e.g. "keyword"
+// for the tok-keyword class.
+func (t TokenType) String() string {
+	if int(t) < len(tokenTypeNames) {
+		return tokenTypeNames[t]
+	}
+	return "text"
+}
+
+type Token struct {
+	Type  TokenType
+	Value string
+}
+
+// tokenList collects tokens, merging runs of the same type so the rendered
+// markup stays small.
+type tokenList []Token
+
+func (l *tokenList) add(t TokenType, value string) {
+	if value == "" {
+		return
+	}
+	if n := len(*l); n > 0 && (*l)[n-1].Type == t {
+		(*l)[n-1].Value += value
+		return
+	}
+	*l = append(*l, Token{Type: t, Value: value})
+}
+
+// SplitLines splits tokens at newlines, so that each line can be rendered on
+// its own. Tokens spanning several lines, such as block comments, are cut
+// into one token per line.
+func SplitLines(tokens []Token) [][]Token {
+	lines := [][]Token{nil}
+	for _, token := range tokens {
+		parts := strings.Split(token.Value, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				last := len(lines) - 1
+				lines[last] = append(lines[last], Token{Type: token.Type, Value: part})
+			}
+		}
+	}
+	return lines
+}
@@ -1,9 +0,0 @@
-package renderer
-
-import (
-    "markee/internal/ast"
-)
-
-type Renderer interface {
-    Render(ast.Document) string
-}
@@ -0,0 +1,101 @@
+package markee
+
+import (
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+// Markdown parses and renders documents with a fixed set of options and
+// extensions. It is safe to use from several goroutines at once.
+type Markdown struct {
+	parserOptions []parser.Option
+	htmlOptions   []renderer.HTMLOption
+	parser        *parser.Parser
+}
+
+// Option configures a Markdown.
+type Option func(*Markdown)
+
+// Extension adds behaviour to a Markdown, typically by registering parser or
+// render options. Extensions are applied in the order they are given.
+type Extension interface {
+	Extend(m *Markdown)
+}
+
+// WithParserOptions applies opts to the parser, e.g. to enable or disable
+// syntax extensions.
+func WithParserOptions(opts ...parser.Option) Option {
+	return func(m *Markdown) {
+		m.AddParserOptions(opts...)
+	}
+}
+
+// WithHTMLOptions applies opts to every HTML rendering.
+func WithHTMLOptions(opts ...renderer.HTMLOption) Option {
+	return func(m *Markdown) {
+		m.AddHTMLOptions(opts...)
+	}
+}
+
+// WithExtensions applies each extension to the Markdown.
+func WithExtensions(extensions ...Extension) Option {
+	return func(m *Markdown) {
+		for _, extension := range extensions {
+			extension.Extend(m)
+		}
+	}
+}
+
+func New(opts ...Option) *Markdown {
+	m := &Markdown{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.parser = parser.New(m.parserOptions...)
+	return m
+}
+
+// AddParserOptions appends options used to build the parser. It is meant to
+// be called by extensions while the Markdown is being built.
+func (m *Markdown) AddParserOptions(opts ...parser.Option) {
+	m.parserOptions = append(m.parserOptions, opts...)
+}
+
+// AddHTMLOptions appends options used when rendering HTML. It is meant to be
+// called by extensions while the Markdown is being built.
+func (m *Markdown) AddHTMLOptions(opts ...renderer.HTMLOption) {
+	m.htmlOptions = append(m.htmlOptions, opts...)
+}
+
+// Parse parses source into a document tree.
+func (m *Markdown) Parse(source string) *ast.Document {
+	return m.parser.Parse(source)
+}
+
+// Render renders doc as HTML.
+func (m *Markdown) Render(doc *ast.Document) string {
+	return renderer.NewHTMLRenderer(m.htmlOptions...).Render(doc)
+}
+
+// Convert parses source and renders it as HTML.
+func (m *Markdown) Convert(source string) string {
+	return m.Render(m.Parse(source))
+}
+
+var defaultMarkdown = New()
+
+// Parse parses source into a document tree with the default options.
+func Parse(source string) *ast.Document {
+	return defaultMarkdown.Parse(source)
+}
+
+// Render renders doc as HTML with the default options.
+func Render(doc *ast.Document) string {
+	return defaultMarkdown.Render(doc)
+}
+
+// Convert parses source and renders it as HTML with the default options.
+func Convert(source string) string {
+	return defaultMarkdown.Convert(source)
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"github.com/rybkr/markee/ast"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExpandAbbreviations wraps each whole-word occurrence of a label defined in
+// doc in an Abbreviation node. Code spans and code blocks are left untouched.
+// The definitions stay in the tree, where renderers to HTML skip them and
+// the Markdown renderer prints them back in place.
+func ExpandAbbreviations(doc *ast.Document) {
+	collector := &abbreviationCollector{titles: make(map[string]string)}
+	doc.Accept(collector)
+
+	if len(collector.titles) == 0 {
+		return
+	}
+
+	labels := make([]string, 0, len(collector.titles))
+	for label := range collector.titles {
+		labels = append(labels, label)
+	}
+	// Try longer labels first so "HTML5" wins over "HTML".
+	sort.Slice(labels, func(i, j int) bool {
+		if len(labels[i]) != len(labels[j]) {
+			return len(labels[i]) > len(labels[j])
+		}
+		return labels[i] < labels[j]
+	})
+
+	expander := &abbreviationExpander{titles: collector.titles, labels: labels}
+	doc.Accept(expander)
+}
+
+type abbreviationCollector struct {
+	ast.BaseVisitor
+	titles map[string]string
+}
+
+func (c *abbreviationCollector) VisitDocument(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *abbreviationCollector) VisitBlockQuote(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *abbreviationCollector) VisitList(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *abbreviationCollector) VisitListItem(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *abbreviationCollector) VisitFencedDiv(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *abbreviationCollector) VisitNode(node ast.Node) {
+	if node.Type().IsContainer() {
+		ast.WalkChildren(c, node)
+	}
+}
+
+func (c *abbreviationCollector) VisitAbbreviationDefinition(node ast.Node) {
+	def := node.(*ast.AbbreviationDefinition)
+	// As with link references, the first definition of a label wins.
+	if _, ok := c.titles[def.Label]; !ok {
+		c.titles[def.Label] = def.Title
+	}
+}
+
+type abbreviationExpander struct {
+	ast.BaseVisitor
+	titles map[string]string
+	labels []string
+}
+
+func (e *abbreviationExpander) VisitDocument(node ast.Node) {
+	ast.WalkChildren(e, node)
+}
+
+func (e *abbreviationExpander) VisitBlockQuote(node ast.Node) {
+	ast.WalkChildren(e, node)
+}
+
+func (e *abbreviationExpander) VisitList(node ast.Node) {
+	ast.WalkChildren(e, node)
+}
+
+func (e *abbreviationExpander) VisitListItem(node ast.Node) {
+	ast.WalkChildren(e, node)
+}
+
+func (e *abbreviationExpander) VisitFencedDiv(node ast.Node) {
+	ast.WalkChildren(e, node)
+}
+
+func (e *abbreviationExpander) VisitNode(node ast.Node) {
+	if node.Type().IsContainer() {
+		ast.WalkChildren(e, node)
+	}
+}
+
+func (e *abbreviationExpander) VisitHeading(node ast.Node) {
+	e.expandChildren(node)
+}
+
+func (e *abbreviationExpander) VisitParagraph(node ast.Node) {
+	e.expandChildren(node)
+}
+
+func (e *abbreviationExpander) VisitEmphasis(node ast.Node) {
+	e.expandChildren(node)
+}
+
+func (e *abbreviationExpander) VisitStrong(node ast.Node) {
+	e.expandChildren(node)
+}
+
+func (e *abbreviationExpander) VisitStrikethrough(node ast.Node) {
+	e.expandChildren(node)
+}
+
+func (e *abbreviationExpander) VisitLink(node ast.Node) {
+	e.expandChildren(node)
+}
+
+// expandChildren rewrites the text children of an inline container. The
+// children are snapshotted first because expansion replaces nodes in place.
+func (e *abbreviationExpander) expandChildren(node ast.Node) {
+	for _, child := range node.Children() {
+		if content, ok := child.(*ast.Content); ok {
+			e.expandContent(node, content)
+		} else {
+			child.Accept(e)
+		}
+	}
+}
+
+func (e *abbreviationExpander) expandContent(parent ast.Node, content *ast.Content) {
+	text := content.Literal
+	var nodes []ast.Node
+	start := 0
+
+	for pos := 0; pos < len(text); {
+		label := e.matchAt(content, pos)
+		if label == "" {
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			pos += size
+			continue
+		}
+
+		if pos > start {
+			nodes = append(nodes, splitContent(content, start, pos))
+		}
+		abbr := ast.NewAbbreviation(e.titles[label])
+		abbr.AddChild(splitContent(content, pos, pos+len(label)))
+		abbr.SetPos(abbr.FirstChild().Pos())
+		nodes = append(nodes, abbr)
+
+		pos += len(label)
+		start = pos
+	}
+
+	if len(nodes) == 0 {
+		return
+	}
+	if start < len(text) {
+		nodes = append(nodes, splitContent(content, start, len(text)))
+	}
+
+	var prev ast.Node = content
+	for _, node := range nodes {
+		parent.InsertAfter(prev, node)
+		prev = node
+	}
+	parent.RemoveChild(content)
+}
+
+// splitContent returns a text node for content.Literal[start:end]. It is
+// positioned within content when the literal is a verbatim copy of a single
+// source line, and given the whole of content's position otherwise.
+func splitContent(content *ast.Content, start, end int) *ast.Content {
+	node := ast.NewContent(content.Literal[start:end])
+	pos := content.Pos()
+	if pos.Start.Line == pos.End.Line && pos.End.Offset-pos.Start.Offset == len(content.Literal) {
+		pos.End = pos.Start
+		pos.Start.Column += start
+		pos.Start.Offset += start
+		pos.End.Column += end
+		pos.End.Offset += end
+	}
+	node.SetPos(pos)
+	return node
+}
+
+// matchAt returns the longest label that occurs as a whole word at pos in
+// content, looking into neighbouring text nodes at the edges.
+func (e *abbreviationExpander) matchAt(content *ast.Content, pos int) string {
+	text := content.Literal
+	if isWordRune(runeBefore(content, pos)) {
+		return ""
+	}
+	for _, label := range e.labels {
+		if !strings.HasPrefix(text[pos:], label) {
+			continue
+		}
+		if !isWordRune(runeAfter(content, pos+len(label))) {
+			return label
+		}
+	}
+	return ""
+}
+
+func runeBefore(content *ast.Content, pos int) rune {
+	if pos > 0 {
+		r, _ := utf8.DecodeLastRuneInString(content.Literal[:pos])
+		return r
+	}
+	if prev, ok := content.PrevSibling().(*ast.Content); ok && len(prev.Literal) > 0 {
+		r, _ := utf8.DecodeLastRuneInString(prev.Literal)
+		return r
+	}
+	return ' '
+}
+
+func runeAfter(content *ast.Content, pos int) rune {
+	if pos < len(content.Literal) {
+		r, _ := utf8.DecodeRuneInString(content.Literal[pos:])
+		return r
+	}
+	if next, ok := content.NextSibling().(*ast.Content); ok && len(next.Literal) > 0 {
+		r, _ := utf8.DecodeRuneInString(next.Literal)
+		return r
+	}
+	return ' '
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"github.com/rybkr/markee/ast"
+	"testing"
+)
+
+func TestAbbreviationDefinitionsKept(t *testing.T) {
+	input := `*[HTML]: Hyper Text Markup Language
+*[CSS]: Cascading Style Sheets
+
+foo`
+	doc := Parse(input)
+	assertChildCount(t, doc, 3)
+	def := assertChild(t, doc, 1, ast.NodeAbbreviationDefinition).(*ast.AbbreviationDefinition)
+	if def.Label != "CSS" || def.Title != "Cascading Style Sheets" {
+		t.Errorf("unexpected definition %q: %q", def.Label, def.Title)
+	}
+	p := assertChild(t, doc, 2, ast.NodeParagraph)
+	assertContent(t, assertChild(t, p, 0, ast.NodeContent), "foo")
+}
+
+func TestAbbreviationWholeWords(t *testing.T) {
+	input := `HTML and XHTML, HTML5 or HTML-ish
+
+*[HTML]: Hyper Text Markup Language`
+	doc := Parse(input)
+	assertChildCount(t, doc, 2)
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 4)
+
+	abbr := assertChild(t, p, 0, ast.NodeAbbreviation).(*ast.Abbreviation)
+	if abbr.Title != "Hyper Text Markup Language" {
+		t.Errorf("unexpected title %q", abbr.Title)
+	}
+	assertContent(t, assertChild(t, abbr, 0, ast.NodeContent), "HTML")
+	assertContent(t, assertChild(t, p, 1, ast.NodeContent), " and XHTML, HTML5 or ")
+	assertChild(t, p, 2, ast.NodeAbbreviation)
+	assertContent(t, assertChild(t, p, 3, ast.NodeContent), "-ish")
+}
+
+func TestAbbreviationLongestLabel(t *testing.T) {
+	input := `HTML5
+
+*[HTML]: Hyper Text Markup Language
+*[HTML5]: HTML version 5`
+	doc := Parse(input)
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 1)
+	abbr := assertChild(t, p, 0, ast.NodeAbbreviation).(*ast.Abbreviation)
+	if abbr.Title != "HTML version 5" {
+		t.Errorf("expected the HTML5 definition, got %q", abbr.Title)
+	}
+}
+
+func TestAbbreviationSkipsCode(t *testing.T) {
+	input := "`HTML`\n\n*[HTML]: Hyper Text Markup Language"
+	doc := Parse(input)
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 1)
+	assertChild(t, p, 0, ast.NodeCodeSpan)
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"github.com/rybkr/markee/ast"
+	"strings"
+)
+
+// parseAttributes parses a Pandoc-style attribute list such as
+// {#intro .note .wide data-level=2 title="Read me"}. The surrounding braces
+// are required. It reports false if the list is malformed.
+func parseAttributes(s string) ([]ast.Attribute, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, false
+	}
+	s = s[1 : len(s)-1]
+
+	attrs := make([]ast.Attribute, 0)
+	for pos := 0; pos < len(s); {
+		c := s[pos]
+		if c == ' ' || c == '\t' {
+			pos++
+			continue
+		}
+
+		switch c {
+		case '#', '.':
+			start := pos + 1
+			end := scanAttributeName(s, start)
+			if end == start {
+				return nil, false
+			}
+			key := "id"
+			if c == '.' {
+				key = "class"
+			}
+			attrs = append(attrs, ast.Attribute{Key: key, Value: s[start:end]})
+			pos = end
+		default:
+			end := scanAttributeName(s, pos)
+			if !ast.IsValidAttributeKey(s[pos:end]) {
+				return nil, false
+			}
+			key := s[pos:end]
+			pos = end
+
+			if pos >= len(s) || s[pos] != '=' {
+				attrs = append(attrs, ast.Attribute{Key: key})
+				continue
+			}
+			pos++ // consume '='
+
+			value, next, ok := scanAttributeValue(s, pos)
+			if !ok {
+				return nil, false
+			}
+			attrs = append(attrs, ast.Attribute{Key: key, Value: value})
+			pos = next
+		}
+	}
+	return attrs, true
+}
+
+func scanAttributeName(s string, pos int) int {
+	for pos < len(s) {
+		c := s[pos]
+		if c == ' ' || c == '\t' || c == '=' || c == '"' || c == '\'' || c == '{' || c == '}' {
+			break
+		}
+		pos++
+	}
+	return pos
+}
+
+func scanAttributeValue(s string, pos int) (string, int, bool) {
+	if pos < len(s) && (s[pos] == '"' || s[pos] == '\'') {
+		quote := s[pos]
+		end := strings.IndexByte(s[pos+1:], quote)
+		if end < 0 {
+			return "", 0, false
+		}
+		return s[pos+1 : pos+1+end], pos + end + 2, true
+	}
+
+	start := pos
+	for pos < len(s) && s[pos] != ' ' && s[pos] != '\t' {
+		pos++
+	}
+	if pos == start {
+		return "", 0, false
+	}
+	return s[start:pos], pos, true
+}
@@ -1,7 +1,8 @@
 package parser
 
 import (
-	"markee/internal/ast"
+	"github.com/rybkr/markee/ast"
+	"slices"
 	"testing"
 )
 
@@ -441,3 +442,161 @@ func TestFencedCodeBlockBacktick(t *testing.T) {
     line2 := assertChild(t, codeBlock, 1, ast.NodeContent)
     assertContent(t, line2, " >")
 }
+
+func TestFencedDiv(t *testing.T) {
+	input := `::: warning {#careful .big}
+foo
+:::
+bar`
+	doc := Parse(input)
+	assertChildCount(t, doc, 2)
+	div := assertChild(t, doc, 0, ast.NodeFencedDiv).(*ast.FencedDiv)
+	if div.Name != "warning" {
+		t.Errorf("expected name %q, got %q", "warning", div.Name)
+	}
+	expected := []ast.Attribute{{Key: "id", Value: "careful"}, {Key: "class", Value: "big"}}
+	if len(div.Attributes) != len(expected) {
+		t.Fatalf("expected %d attributes, got %d", len(expected), len(div.Attributes))
+	}
+	for i, attr := range expected {
+		if div.Attributes[i] != attr {
+			t.Errorf("attribute %d: expected %v, got %v", i, attr, div.Attributes[i])
+		}
+	}
+	p1 := assertChild(t, div, 0, ast.NodeParagraph)
+	assertContent(t, assertChild(t, p1, 0, ast.NodeContent), "foo")
+	p2 := assertChild(t, doc, 1, ast.NodeParagraph)
+	assertContent(t, assertChild(t, p2, 0, ast.NodeContent), "bar")
+}
+
+func TestFencedDivNested(t *testing.T) {
+	input := `:::: outer
+::: inner
+foo
+:::
+bar
+::::`
+	doc := Parse(input)
+	assertChildCount(t, doc, 1)
+	outer := assertChild(t, doc, 0, ast.NodeFencedDiv)
+	assertChildCount(t, outer, 2)
+	inner := assertChild(t, outer, 0, ast.NodeFencedDiv)
+	assertChildCount(t, inner, 1)
+	assertChild(t, outer, 1, ast.NodeParagraph)
+}
+
+func TestFencedDivSameFenceLength(t *testing.T) {
+	input := `::: outer
+::: inner
+foo
+:::
+:::
+bar`
+	doc := Parse(input)
+	assertChildCount(t, doc, 2)
+	outer := assertChild(t, doc, 0, ast.NodeFencedDiv)
+	inner := assertChild(t, outer, 0, ast.NodeFencedDiv)
+	assertChildCount(t, inner, 1)
+	assertChild(t, doc, 1, ast.NodeParagraph)
+}
+
+func TestFencedDivCodeBlockNotClosed(t *testing.T) {
+	input := "::: note\n```\n:::\n```\n:::"
+	doc := Parse(input)
+	assertChildCount(t, doc, 1)
+	div := assertChild(t, doc, 0, ast.NodeFencedDiv)
+	codeBlock := assertChild(t, div, 0, ast.NodeCodeBlock)
+	assertContent(t, assertChild(t, codeBlock, 0, ast.NodeContent), ":::")
+}
+
+func TestFencedDivRequiresName(t *testing.T) {
+	doc := Parse(":::")
+	assertChildCount(t, doc, 1)
+	assertChild(t, doc, 0, ast.NodeParagraph)
+}
+
+func TestFencedDivHostileAttributeKeys(t *testing.T) {
+	for _, input := range []string{
+		"::: note {onmouseover=alert(1) x><script>alert(1)</script}\nfoo\n:::",
+		"::: note {a\"b=1}\nfoo\n:::",
+		"::: note {1a=1}\nfoo\n:::",
+	} {
+		doc := Parse(input)
+		if doc.FirstChild().Type() == ast.NodeFencedDiv {
+			t.Errorf("%q: expected the attribute list to be rejected", input)
+		}
+	}
+
+	doc := Parse("::: note {data-x.y:z=1 _a}\nfoo\n:::")
+	div := assertChild(t, doc, 0, ast.NodeFencedDiv).(*ast.FencedDiv)
+	expected := []ast.Attribute{{Key: "data-x.y:z", Value: "1"}, {Key: "_a"}}
+	if !slices.Equal(div.Attributes, expected) {
+		t.Errorf("expected %v, got %v", expected, div.Attributes)
+	}
+}
+
+func TestFencedCodeBlockInfoAttributes(t *testing.T) {
+	input := "```go {linenos=true hl_lines=\"2-4\" title=\"main.go\" start=10}\nfoo\n```"
+	doc := Parse(input)
+	assertChildCount(t, doc, 1)
+	codeBlock := assertChild(t, doc, 0, ast.NodeCodeBlock).(*ast.CodeBlock)
+	if codeBlock.Language != "go" {
+		t.Errorf("expected language %q, got %q", "go", codeBlock.Language)
+	}
+
+	expected := []ast.Attribute{
+		{Key: "linenos", Value: "true"},
+		{Key: "hl_lines", Value: "2-4"},
+		{Key: "title", Value: "main.go"},
+		{Key: "start", Value: "10"},
+	}
+	if len(codeBlock.Attributes) != len(expected) {
+		t.Fatalf("expected %d attributes, got %d", len(expected), len(codeBlock.Attributes))
+	}
+	for i, attr := range expected {
+		if codeBlock.Attributes[i] != attr {
+			t.Errorf("attribute %d: expected %v, got %v", i, attr, codeBlock.Attributes[i])
+		}
+	}
+}
+
+func TestFencedCodeBlockPandocInfo(t *testing.T) {
+	doc := Parse("``` {.python title=\"x.py\"}\nfoo\n```")
+	codeBlock := assertChild(t, doc, 0, ast.NodeCodeBlock).(*ast.CodeBlock)
+	if codeBlock.Language != "python" {
+		t.Errorf("expected language %q, got %q", "python", codeBlock.Language)
+	}
+	if title, _ := ast.LookupAttribute(codeBlock.Attributes, "title"); title != "x.py" {
+		t.Errorf("expected title %q, got %q", "x.py", title)
+	}
+}
+
+func TestFencedCodeBlockInfoWithoutAttributes(t *testing.T) {
+	doc := Parse("~~~~    ruby startline=3 $%@#$\nfoo\n~~~~")
+	codeBlock := assertChild(t, doc, 0, ast.NodeCodeBlock).(*ast.CodeBlock)
+	if codeBlock.Language != "ruby" || len(codeBlock.Attributes) != 0 {
+		t.Errorf("expected ruby without attributes, got %q %v", codeBlock.Language, codeBlock.Attributes)
+	}
+}
+
+func TestConsecutiveFencedCodeBlocks(t *testing.T) {
+	doc := Parse("```\nfoo\n```\n\n```\nbar\n```")
+	assertChildCount(t, doc, 2)
+	first := assertChild(t, doc, 0, ast.NodeCodeBlock)
+	assertChildCount(t, first, 1)
+	second := assertChild(t, doc, 1, ast.NodeCodeBlock)
+	assertContent(t, assertChild(t, second, 0, ast.NodeContent), "bar")
+}
+
+func TestBlockInterruptsParagraph(t *testing.T) {
+	doc := Parse("foo\n***\nbar\n> baz\n# qux")
+	assertChildCount(t, doc, 5)
+	assertContent(t, assertChild(t, assertChild(t, doc, 0, ast.NodeParagraph), 0, ast.NodeContent), "foo")
+	assertChild(t, doc, 1, ast.NodeThematicBreak)
+	assertContent(t, assertChild(t, assertChild(t, doc, 2, ast.NodeParagraph), 0, ast.NodeContent), "bar")
+	assertChild(t, doc, 3, ast.NodeBlockQuote)
+	assertChild(t, doc, 4, ast.NodeHeading)
+	if err := ast.Validate(doc); err != nil {
+		t.Error(err)
+	}
+}
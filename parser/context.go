@@ -1,12 +1,14 @@
 package parser
 
 import (
-    "markee/internal/ast"
+    "github.com/rybkr/markee/ast"
 )
 
 type Context struct {
     Doc *ast.Document
     Tip ast.Node
+
+    diagnostics *diagnostics
 }
 
 func NewContext() *Context {
@@ -1,7 +1,7 @@
 package parser
 
 import (
-    "markee/internal/ast"
+    "github.com/rybkr/markee/ast"
 )
 
 type DelimiterType int
@@ -21,6 +21,7 @@ type Delimiter struct {
     CanOpen       bool
     CanClose      bool
     ContentNode   *ast.Content
+    Start         int // offset of the run in the inline input
     Prev          *Delimiter
     Next          *Delimiter
 }
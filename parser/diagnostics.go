@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// Severity ranks a Diagnostic. Every input is valid Markdown, so diagnostics
+// only point out what was probably not meant.
+type Severity int
+
+const (
+	// SeverityInfo marks input that is often intended, such as a lone *.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks input that is rarely intended.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "info"
+}
+
+// Codes identify the kind of a Diagnostic.
+const (
+	// CodeUnclosedFence is a fenced code block without a closing fence, which
+	// runs to the end of its container.
+	CodeUnclosedFence = "unclosed-fence"
+	// CodeUnresolvedReference is a full or collapsed reference link, such as
+	// [text][label] or [label][], whose label has no definition.
+	CodeUnresolvedReference = "unresolved-reference"
+	// CodeDuplicateDefinition is a reference definition for a label that was
+	// already defined. The first definition wins.
+	CodeDuplicateDefinition = "duplicate-definition"
+	// CodeUnmatchedEmphasis is a run of * or _ that could open or close
+	// emphasis but was left as text.
+	CodeUnmatchedEmphasis = "unmatched-emphasis"
+	// CodeHeadingWithoutSpace is a line such as #Title, which is a paragraph
+	// because a heading needs a space after the #.
+	CodeHeadingWithoutSpace = "heading-without-space"
+)
+
+// Diagnostic points out suspicious input found while parsing.
+type Diagnostic struct {
+	Pos      ast.Position
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// String formats the diagnostic as
+// "3:1-3:6: warning: heading needs a space after # (heading-without-space)".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", d.Pos, d.Severity, d.Message, d.Code)
+}
+
+// ParseWithDiagnostics parses input with the default extensions, returning
+// diagnostics as well.
+func ParseWithDiagnostics(input string) (*ast.Document, []Diagnostic) {
+	return defaultParser.ParseWithDiagnostics(input)
+}
+
+// ParseWithDiagnostics is Parse that also returns diagnostics for suspicious
+// input, in source order.
+func (p *Parser) ParseWithDiagnostics(input string) (*ast.Document, []Diagnostic) {
+	d := &diagnostics{definitions: make(map[string]bool)}
+	doc, _ := p.parse(strings.NewReader(input), d)
+	return doc, d.finish(doc)
+}
+
+// diagnostics collects the diagnostics of one parse. Its methods do nothing
+// on a nil *diagnostics, which Parse uses.
+type diagnostics struct {
+	list        []Diagnostic
+	definitions map[string]bool
+	references  []reference
+}
+
+// reference is a reference link, whose label is checked once every
+// definition has been seen.
+type reference struct {
+	label string
+	pos   ast.Position
+}
+
+func (d *diagnostics) report(pos ast.Position, severity Severity, code, format string, args ...any) {
+	if d == nil {
+		return
+	}
+	d.list = append(d.list, Diagnostic{Pos: pos, Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+var (
+	reHeadingWithoutSpace = regexp.MustCompile(`^#{1,6}[^#\s]`)
+	reDefinition          = regexp.MustCompile(`^\[((?:[^\[\]\\]|\\.)+)\]:[ \t]*\S`)
+)
+
+// checkLine reports a paragraph line that looks like a heading.
+func (d *diagnostics) checkLine(content *ast.Content) {
+	if d != nil && reHeadingWithoutSpace.MatchString(content.Literal) {
+		d.report(content.Pos(), SeverityWarning, CodeHeadingWithoutSpace, "heading needs a space after #")
+	}
+}
+
+// addDefinitions records the lines of the form [label]: destination at the
+// start of a paragraph, which CommonMark reads as reference definitions.
+func (d *diagnostics) addDefinitions(paragraph ast.Node) {
+	if d == nil {
+		return
+	}
+	for child := paragraph.FirstChild(); child != nil; child = child.NextSibling() {
+		content, ok := child.(*ast.Content)
+		if !ok {
+			return
+		}
+		matches := reDefinition.FindStringSubmatch(content.Literal)
+		if matches == nil {
+			return
+		}
+		label := normalizeLabel(matches[1])
+		if d.definitions[label] {
+			d.report(content.Pos(), SeverityWarning, CodeDuplicateDefinition, "reference %q is already defined", matches[1])
+		}
+		d.definitions[label] = true
+	}
+}
+
+func (d *diagnostics) addReference(label string, pos ast.Position) {
+	if d != nil {
+		d.references = append(d.references, reference{label, pos})
+	}
+}
+
+// finish reports what can only be found once doc is complete and returns the
+// diagnostics in source order.
+func (d *diagnostics) finish(doc *ast.Document) []Diagnostic {
+	for _, ref := range d.references {
+		if !d.definitions[normalizeLabel(ref.label)] {
+			d.report(ref.pos, SeverityWarning, CodeUnresolvedReference, "reference %q is not defined", ref.label)
+		}
+	}
+	for node := range ast.All(doc) {
+		if code, ok := node.(*ast.CodeBlock); ok && code.IsFenced && code.IsOpen() {
+			d.report(code.Pos(), SeverityWarning, CodeUnclosedFence, "fenced code block is never closed")
+		}
+	}
+
+	sort.SliceStable(d.list, func(i, j int) bool {
+		return d.list[i].Pos.Start.Offset < d.list[j].Pos.Start.Offset
+	})
+	return d.list
+}
+
+// normalizeLabel matches labels as CommonMark does, ignoring case and runs of
+// whitespace.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
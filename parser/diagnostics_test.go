@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+)
+
+func TestParseWithDiagnostics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"clean", "# Title\n\nSome *text* and [a link](/url).\n\n```\ncode\n```", nil},
+		{"unclosed fence", "Text\n\n```go\ncode\n", []string{
+			"3:1-4:4: warning: fenced code block is never closed (unclosed-fence)",
+		}},
+		{"unclosed fence in quote", "> ```\n> code\n\nafter", []string{
+			"1:3-2:6: warning: fenced code block is never closed (unclosed-fence)",
+		}},
+		{"heading without space", "#Title\n\ntext\n###Sub", []string{
+			"1:1-1:6: warning: heading needs a space after # (heading-without-space)",
+			"4:1-4:6: warning: heading needs a space after # (heading-without-space)",
+		}},
+		{"hashtag mid-line", "tagged #markdown", nil},
+		{"reference links", "[x][foo] and [Foo][]\n\n[foo]: /url", nil},
+		{"unresolved references", "[x][foo], [Foo  Bar][] and [y][bar]\n\n[foo bar]: /url", []string{
+			"1:1-1:8: warning: reference \"foo\" is not defined (unresolved-reference)",
+			"1:28-1:35: warning: reference \"bar\" is not defined (unresolved-reference)",
+		}},
+		{"duplicate definitions", "[a]: /one\n[b]: /two\n[A]: /three", []string{
+			"3:1-3:11: warning: reference \"A\" is already defined (duplicate-definition)",
+		}},
+		{"unmatched emphasis", "a *b and __c d\n\n2 * 3 and snake_case_name", []string{
+			"1:3-1:3: info: \"*\" does not open or close emphasis (unmatched-emphasis)",
+			"1:10-1:11: info: \"__\" does not open or close emphasis (unmatched-emphasis)",
+		}},
+		{"leftover delimiters", "**a* and *b **c*", []string{
+			"1:1-1:1: info: \"*\" does not open or close emphasis (unmatched-emphasis)",
+			"1:10-1:10: info: \"*\" does not open or close emphasis (unmatched-emphasis)",
+			"1:13-1:13: info: \"*\" does not open or close emphasis (unmatched-emphasis)",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diagnostics := New().ParseWithDiagnostics(tt.input)
+			var got []string
+			for _, d := range diagnostics {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expected\n%s\ngot\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseWithDiagnosticsMatchesParse(t *testing.T) {
+	input := "#Title\n\n*[HTML]: HyperText Markup Language\n\nSome *HTML [x][y]\n\n```\ncode"
+	doc, _ := New().ParseWithDiagnostics(input)
+	if !ast.Equal(doc, Parse(input)) {
+		t.Errorf("expected the same document as Parse")
+	}
+}
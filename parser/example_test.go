@@ -0,0 +1,46 @@
+package parser_test
+
+import (
+	"fmt"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+// issueReferences links #123 to the project's issue tracker.
+type issueReferences struct {
+	baseURL string
+}
+
+func (issueReferences) Name() string { return "issue_references" }
+
+func (e issueReferences) Extend(r *parser.Registry) {
+	r.AddInlineSyntax(e)
+}
+
+func (issueReferences) Triggers() []byte { return []byte{'#'} }
+
+func (e issueReferences) Parse(p *parser.InlineParser) bool {
+	input, start := p.Input(), p.Pos()
+	end := start + 1
+	for end < len(input) && input[end] >= '0' && input[end] <= '9' {
+		end++
+	}
+	if end == start+1 {
+		return false
+	}
+
+	link := ast.NewLink(e.baseURL+input[start+1:end], "")
+	link.AddChild(ast.NewContent(input[start:end]))
+	p.Container().AddChild(link)
+	p.Advance(end - start)
+	return true
+}
+
+func ExampleRegistry_AddInlineSyntax() {
+	p := parser.New(parser.WithExtensions(issueReferences{baseURL: "https://example.com/issues/"}))
+	fmt.Print(renderer.RenderHTML(p.Parse("Fixed in #123.")))
+	// Output:
+	// <p>Fixed in <a href="https://example.com/issues/123">#123</a>.</p>
+}
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"markee/internal/ast"
+	"github.com/rybkr/markee/ast"
 )
 
 type BlockExtender struct {
@@ -9,6 +9,7 @@ type BlockExtender struct {
 	line       *Line
 	lastMatch  ast.Node
 	allMatched []ast.Node
+	closed     ast.Node
 }
 
 func NewBlockExtender(line *Line) *BlockExtender {
@@ -27,6 +28,11 @@ func (e *BlockExtender) AllMatched() []ast.Node {
     return e.allMatched
 }
 
+// Closed returns the block closed by a fence on the line, if any.
+func (e *BlockExtender) Closed() ast.Node {
+    return e.closed
+}
+
 func (e *BlockExtender) VisitDocument(node ast.Node) {
 	e.lastMatch = node
     e.allMatched = append(e.allMatched, node)
@@ -57,9 +63,64 @@ func (e *BlockExtender) VisitListItem(node ast.Node) {
     ast.WalkLastChild(e, node)
 }
 
+func (e *BlockExtender) VisitFencedDiv(node ast.Node) {
+    div, ok := node.(*ast.FencedDiv)
+    if !ok || !div.IsOpen() {
+        return
+    }
+
+    if e.isClosingDivFence(div) {
+        e.line.ConsumeAll()
+        closeOpenBlocks(div)
+        e.closed = div
+        return
+    }
+    e.lastMatch = node
+    e.allMatched = append(e.allMatched, node)
+    ast.WalkLastChild(e, node)
+}
+
+// isClosingDivFence reports whether the line closes div. A colon fence closes
+// the innermost open div it is long enough for, so a nested div with a
+// shorter or equal fence gets the first chance to claim it.
+func (e *BlockExtender) isClosingDivFence(div *ast.FencedDiv) bool {
+    if e.line.Indent >= 4 {
+        return false
+    }
+
+    fenceCount := 0
+    for fenceCount < len(e.line.Content) && e.line.Content[fenceCount] == ':' {
+        fenceCount++
+    }
+    if fenceCount < 3 || fenceCount < div.FenceLen || !stringIsBlank(e.line.Content[fenceCount:]) {
+        return false
+    }
+
+    for child := div.LastChild(); child != nil && child.IsOpen(); child = child.LastChild() {
+        switch c := child.(type) {
+        case *ast.FencedDiv:
+            if fenceCount >= c.FenceLen {
+                return false
+            }
+        case *ast.CodeBlock:
+            return !c.IsFenced
+        case *ast.BlockQuote, *ast.Paragraph:
+            return true
+        }
+    }
+    return true
+}
+
+// closeOpenBlocks closes node along with its chain of open last children.
+func closeOpenBlocks(node ast.Node) {
+    for ; node != nil && node.IsOpen(); node = node.LastChild() {
+        node.SetOpen(false)
+    }
+}
+
 func (e *BlockExtender) VisitCodeBlock(node ast.Node) {
     codeBlock, ok := node.(*ast.CodeBlock)
-    if !ok {
+    if !ok || !codeBlock.IsOpen() {
         return
     }
 
@@ -67,6 +128,7 @@ func (e *BlockExtender) VisitCodeBlock(node ast.Node) {
         if e.isClosingFence(codeBlock) {
             e.line.ConsumeAll()
             codeBlock.SetOpen(false)
+            e.closed = codeBlock
             return
         }
         e.lastMatch = node
@@ -0,0 +1,76 @@
+package parser
+
+// Built-in extensions beyond CommonMark. All of them are enabled by default.
+var (
+	// TOC recognizes [TOC] and <!-- toc --> placeholder lines.
+	TOC Extension = tocExtension{}
+
+	// FencedDivs recognizes ::: name containers.
+	FencedDivs Extension = fencedDivExtension{}
+
+	// Abbreviations recognizes *[HTML]: ... definitions and marks up every
+	// occurrence of the abbreviation in the text.
+	Abbreviations Extension = abbreviationExtension{}
+)
+
+// GitHub Flavored Markdown extensions, enabled by WithGFM.
+var (
+	// Strikethrough recognizes ~text~ and ~~text~~.
+	Strikethrough Extension = strikethroughExtension{}
+
+	// Autolinks links bare www. and http(s):// addresses in the text.
+	Autolinks Extension = autolinkExtension{}
+)
+
+// DefaultExtensions returns the extensions a Parser enables unless told
+// otherwise.
+func DefaultExtensions() []Extension {
+	return []Extension{TOC, FencedDivs, Abbreviations}
+}
+
+// GFMExtensions returns the extensions WithGFM enables. Tables and task
+// lists are not supported yet.
+func GFMExtensions() []Extension {
+	return []Extension{Strikethrough, Autolinks}
+}
+
+type tocExtension struct{}
+
+func (tocExtension) Name() string { return "toc" }
+
+func (tocExtension) Extend(r *Registry) {
+	r.AddBlockMatcher(BlockMatcher{Priority: 70, Name: "toc", Match: matchTOC, CanInterrupt: CannotInterruptParagraph})
+}
+
+type fencedDivExtension struct{}
+
+func (fencedDivExtension) Name() string { return "fenced_div" }
+
+func (fencedDivExtension) Extend(r *Registry) {
+	r.AddBlockMatcher(BlockMatcher{Priority: 40, Name: "fenced_div", Match: matchFencedDiv, CanInterrupt: AlwaysInterrupt})
+}
+
+type abbreviationExtension struct{}
+
+func (abbreviationExtension) Name() string { return "abbreviation" }
+
+func (abbreviationExtension) Extend(r *Registry) {
+	r.AddBlockMatcher(BlockMatcher{Priority: 80, Name: "abbreviation", Match: matchAbbreviationDefinition, CanInterrupt: CannotInterruptParagraph})
+	r.AddTransform(Transform{Priority: 100, Name: "abbreviation", Apply: ExpandAbbreviations})
+}
+
+type strikethroughExtension struct{}
+
+func (strikethroughExtension) Name() string { return "strikethrough" }
+
+func (strikethroughExtension) Extend(r *Registry) {
+	r.AddInlineTrigger('~', parseStrikethrough)
+}
+
+type autolinkExtension struct{}
+
+func (autolinkExtension) Name() string { return "autolink" }
+
+func (autolinkExtension) Extend(r *Registry) {
+	r.AddTransform(Transform{Priority: 200, Name: "autolink", Apply: ExpandAutolinks})
+}
@@ -1,15 +1,17 @@
 package parser
 
 import (
-	"markee/internal/ast"
+	"github.com/rybkr/markee/ast"
 )
 
 type BlockFinalizer struct {
 	ast.BaseVisitor
+	parser      *Parser
+	diagnostics *diagnostics
 }
 
-func NewBlockFinalizer() *BlockFinalizer {
-	return &BlockFinalizer{}
+func NewBlockFinalizer(p *Parser) *BlockFinalizer {
+	return &BlockFinalizer{parser: p}
 }
 
 func (f *BlockFinalizer) VisitDocument(node ast.Node) {
@@ -28,41 +30,46 @@ func (f *BlockFinalizer) VisitListItem(node ast.Node) {
     ast.WalkChildren(f, node)
 }
 
-func (f *BlockFinalizer) VisitParagraph(node ast.Node) {
-    var content string
-    for child := node.FirstChild(); child != nil; child = child.NextSibling() {
-        if contentNode, ok := child.(*ast.Content); ok {
-            if len(content) > 0 {
-                content += "\n"
-            }
-            content += contentNode.Literal
-        }
-    }
-    
-    for child := node.FirstChild(); child != nil; {
-        next := child.NextSibling()
-        if _, ok := child.(*ast.Content); ok {
-            node.RemoveChild(child)
-        }
-        child = next
+func (f *BlockFinalizer) VisitFencedDiv(node ast.Node) {
+    ast.WalkChildren(f, node)
+}
+
+func (f *BlockFinalizer) VisitNode(node ast.Node) {
+    if node.Type().IsContainer() {
+        ast.WalkChildren(f, node)
     }
-    
-    if len(content) > 0 {
-        ParseInlines(node, content)
+}
+
+func (f *BlockFinalizer) VisitParagraph(node ast.Node) {
+    f.diagnostics.addDefinitions(node)
+    if content, segments := collectInlineContent(node); len(content) > 0 {
+        f.parser.parseInlines(node, content, segments, f.diagnostics)
     }
 }
 
 func (f *BlockFinalizer) VisitHeading(node ast.Node) {
+    if content, segments := collectInlineContent(node); len(content) > 0 {
+        f.parser.parseInlines(node, content, segments, f.diagnostics)
+    }
+}
+
+// collectInlineContent removes the raw lines of text from node, returning
+// them joined by newlines along with where each line starts in the source.
+func collectInlineContent(node ast.Node) (string, []lineSegment) {
     var content string
+    var segments []lineSegment
     for child := node.FirstChild(); child != nil; child = child.NextSibling() {
         if contentNode, ok := child.(*ast.Content); ok {
             if len(content) > 0 {
                 content += "\n"
             }
+            if contentNode.Pos().IsValid() {
+                segments = append(segments, lineSegment{at: len(content), start: contentNode.Pos().Start})
+            }
             content += contentNode.Literal
         }
     }
-    
+
     for child := node.FirstChild(); child != nil; {
         next := child.NextSibling()
         if _, ok := child.(*ast.Content); ok {
@@ -71,9 +78,7 @@ func (f *BlockFinalizer) VisitHeading(node ast.Node) {
         child = next
     }
 
-    if len(content) > 0 {
-        ParseInlines(node, content)
-    }
+    return content, segments
 }
 
 func (f *BlockFinalizer) VisitCodeBlock(node ast.Node) {
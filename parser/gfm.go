@@ -0,0 +1,291 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// parseStrikethrough parses a run of one or two tildes and the text up to a
+// closing run of the same length. The content may not start or end with
+// whitespace, and code spans in it take priority, so tildes inside them do
+// not close it. Runs that do not match, and longer runs, are text.
+func parseStrikethrough(p *InlineParser) bool {
+	start := p.pos
+	n := 0
+	for start+n < len(p.input) && p.input[start+n] == '~' {
+		n++
+	}
+
+	open := start + n
+	if n <= 2 && open < len(p.input) && !isSpaceByte(p.input[open]) {
+		for i := open; i < len(p.input); {
+			switch p.input[i] {
+			case '\\':
+				i += 2
+				continue
+			case '`':
+				i = codeSpanEnd(p.input, i)
+				continue
+			case '~':
+				m := 0
+				for i+m < len(p.input) && p.input[i+m] == '~' {
+					m++
+				}
+				if m == n && !isSpaceByte(p.input[i-1]) {
+					node := ast.NewStrikethrough()
+					p.parseSpan(node, open, i)
+					p.addNode(node, start, i+n)
+					p.pos = i + n
+					return true
+				}
+				i += m
+				continue
+			}
+			i++
+		}
+	}
+
+	p.addText(p.input[start:open], start, open)
+	p.pos = open
+	return true
+}
+
+// codeSpanEnd returns the end of the code span opened by the run of
+// backticks at start, or the end of the run if it opens none.
+func codeSpanEnd(input string, start int) int {
+	n := 0
+	for start+n < len(input) && input[start+n] == '`' {
+		n++
+	}
+	for i := start + n; i < len(input); {
+		if input[i] != '`' {
+			i++
+			continue
+		}
+		m := 0
+		for i+m < len(input) && input[i+m] == '`' {
+			m++
+		}
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return start + n
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// parseSpan parses input[start:end] into container, with its own delimiter
+// stack, keeping the source positions.
+func (p *InlineParser) parseSpan(container ast.Node, start, end int) {
+	sub := NewInlineParser(container, p.input[start:end])
+	sub.triggers = p.triggers
+	sub.diagnostics = p.diagnostics
+	if len(p.segments) > 0 {
+		sub.segments = []lineSegment{{at: 0, start: p.point(start, false)}}
+		for _, seg := range p.segments {
+			if seg.at > start && seg.at < end {
+				sub.segments = append(sub.segments, lineSegment{at: seg.at - start, start: seg.start})
+			}
+		}
+	}
+	sub.parse()
+}
+
+// ExpandAutolinks turns the bare addresses in the text of doc into links, as
+// GFM does: www.example.com, linked over http, and http:// or https:// URLs.
+// Trailing punctuation and unbalanced closing parentheses are left out of
+// the link. Text in links is left untouched.
+func ExpandAutolinks(doc *ast.Document) {
+	var texts []*ast.Content
+	for content := range ast.OfType[*ast.Content](ast.All(doc)) {
+		if !insideLink(content) {
+			texts = append(texts, content)
+		}
+	}
+	for _, content := range texts {
+		if content.Parent() != nil {
+			expandAutolinks(mergeText(content))
+		}
+	}
+}
+
+func insideLink(node ast.Node) bool {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if t := parent.Type(); t == ast.NodeLink || t == ast.NodeImage {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeText joins content with the text nodes that follow it directly in the
+// source, which the inline parser leaves split at characters such as _.
+func mergeText(content *ast.Content) *ast.Content {
+	for {
+		next, ok := content.NextSibling().(*ast.Content)
+		if !ok || !isVerbatim(content) || !isVerbatim(next) || content.Pos().End != next.Pos().Start {
+			return content
+		}
+		pos := content.Pos()
+		pos.End = next.Pos().End
+		content.Literal += next.Literal
+		content.SetPos(pos)
+		content.Parent().RemoveChild(next)
+	}
+}
+
+// isVerbatim reports whether content is a positioned copy of a single source
+// line, which splitContent can place exactly.
+func isVerbatim(content *ast.Content) bool {
+	pos := content.Pos()
+	return pos.IsValid() && pos.Start.Line == pos.End.Line && pos.End.Offset-pos.Start.Offset == len(content.Literal)
+}
+
+func expandAutolinks(content *ast.Content) {
+	text := content.Literal
+	var nodes []ast.Node
+	start := 0
+
+	for pos := 0; pos < len(text); pos++ {
+		if !autolinkCanStart(content, pos) {
+			continue
+		}
+		end := matchAutolink(text[pos:])
+		if end == 0 {
+			continue
+		}
+
+		if pos > start {
+			nodes = append(nodes, splitContent(content, start, pos))
+		}
+		address := text[pos : pos+end]
+		destination := address
+		if strings.HasPrefix(address, "www.") {
+			destination = "http://" + address
+		}
+		link := ast.NewLink(destination, "")
+		link.AddChild(splitContent(content, pos, pos+end))
+		link.SetPos(link.FirstChild().Pos())
+		nodes = append(nodes, link)
+
+		pos += end - 1
+		start = pos + 1
+	}
+
+	if len(nodes) == 0 {
+		return
+	}
+	if start < len(text) {
+		nodes = append(nodes, splitContent(content, start, len(text)))
+	}
+
+	parent := content.Parent()
+	var prev ast.Node = content
+	for _, node := range nodes {
+		parent.InsertAfter(prev, node)
+		prev = node
+	}
+	parent.RemoveChild(content)
+}
+
+// autolinkCanStart reports whether an address may start at pos: at the start
+// of the text, or after whitespace or one of *, _, ~ and (.
+func autolinkCanStart(content *ast.Content, pos int) bool {
+	c := content.Literal[pos]
+	if c != 'w' && c != 'h' {
+		return false
+	}
+	before := runeBefore(content, pos)
+	return before == ' ' || before == '\t' || before == '\n' || strings.ContainsRune("*_~(", before)
+}
+
+// matchAutolink returns the length of the address s starts with, or 0.
+func matchAutolink(s string) int {
+	var rest string
+	switch {
+	case strings.HasPrefix(s, "www."):
+		rest = s
+	case strings.HasPrefix(s, "http://"):
+		rest = s[len("http://"):]
+	case strings.HasPrefix(s, "https://"):
+		rest = s[len("https://"):]
+	default:
+		return 0
+	}
+	prefix := len(s) - len(rest)
+
+	end := strings.IndexAny(rest, " \t\n<")
+	if end < 0 {
+		end = len(rest)
+	}
+	end = trimAutolink(rest[:end])
+
+	domain := rest[:end]
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	if !isValidDomain(domain) {
+		return 0
+	}
+	return prefix + end
+}
+
+// trimAutolink returns the length of address without trailing punctuation,
+// unbalanced closing parentheses and a trailing entity reference.
+func trimAutolink(address string) int {
+	for len(address) > 0 {
+		last := address[len(address)-1]
+		switch {
+		case strings.IndexByte("?!.,:*_~", last) >= 0:
+			address = address[:len(address)-1]
+		case last == ')' && strings.Count(address, ")") > strings.Count(address, "("):
+			address = address[:len(address)-1]
+		case last == ';':
+			i := strings.LastIndexByte(address, '&')
+			if i < 0 || !isAlphanumeric(address[i+1:len(address)-1]) {
+				return len(address)
+			}
+			address = address[:i]
+		default:
+			return len(address)
+		}
+	}
+	return 0
+}
+
+// isValidDomain reports whether domain is segments of letters, digits, _ and
+// - separated by periods, with at least one period and no _ in the last two
+// segments.
+func isValidDomain(domain string) bool {
+	segments := strings.Split(domain, ".")
+	if len(segments) < 2 {
+		return false
+	}
+	for i, segment := range segments {
+		if segment == "" || strings.Trim(segment, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") != "" {
+			return false
+		}
+		if i >= len(segments)-2 && strings.Contains(segment, "_") {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+)
+
+var gfm = New(WithGFM())
+
+func TestGFMProfile(t *testing.T) {
+	doc := gfm.Parse("[TOC]\n\n::: note\nx\n:::")
+	for _, child := range doc.Children() {
+		assertNodeType(t, child, ast.NodeParagraph)
+	}
+
+	doc = Parse("~~a~~ www.example.com")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	for _, child := range p.Children() {
+		assertNodeType(t, child, ast.NodeContent)
+	}
+}
+
+func TestStrikethrough(t *testing.T) {
+	doc := gfm.Parse("a ~~b *c*~~ and ~d~")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 4)
+	del := assertChild(t, p, 1, ast.NodeStrikethrough)
+	assertPos(t, del, "1:3-1:11")
+	assertContent(t, assertChild(t, del, 0, ast.NodeContent), "b ")
+	em := assertChild(t, del, 1, ast.NodeEmphasis)
+	assertPos(t, em, "1:7-1:9")
+	assertContent(t, assertChild(t, p, 2, ast.NodeContent), " and ")
+	assertContent(t, assertChild(t, assertChild(t, p, 3, ast.NodeStrikethrough), 0, ast.NodeContent), "d")
+}
+
+func TestStrikethroughSkipsCodeSpans(t *testing.T) {
+	doc := gfm.Parse("x ~~a `b~~` c~~")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 2)
+	del := assertChild(t, p, 1, ast.NodeStrikethrough)
+	assertChildCount(t, del, 3)
+	assertContent(t, assertChild(t, del, 0, ast.NodeContent), "a ")
+	if code := assertChild(t, del, 1, ast.NodeCodeSpan).(*ast.CodeSpan); code.Literal != "b~~" {
+		t.Errorf("unexpected code %q", code.Literal)
+	}
+	assertContent(t, assertChild(t, del, 2, ast.NodeContent), " c")
+}
+
+func TestStrikethroughNotMatched(t *testing.T) {
+	for _, input := range []string{"~~a~", "~~~a~~~", "~~ a~~", "a ~~b", "~a ~"} {
+		doc := gfm.Parse(input)
+		for node := range ast.All(doc) {
+			if node.Type() == ast.NodeStrikethrough {
+				t.Errorf("%q: unexpected strikethrough", input)
+			}
+		}
+	}
+}
+
+func TestAutolinks(t *testing.T) {
+	tests := []struct {
+		input       string
+		destination string
+		text        string
+	}{
+		{"see www.commonmark.org/help.", "http://www.commonmark.org/help", "www.commonmark.org/help"},
+		{"(https://example.com/a_(b))", "https://example.com/a_(b)", "https://example.com/a_(b)"},
+		{"x http://a.b.com/?q=1&amp;", "http://a.b.com/?q=1", "http://a.b.com/?q=1"},
+		{"*www.example.com*", "http://www.example.com", "www.example.com"},
+	}
+	for _, tt := range tests {
+		doc := gfm.Parse(tt.input)
+		links := ast.OfType[*ast.Link](ast.All(doc))
+		found := false
+		for link := range links {
+			found = true
+			if link.Destination != tt.destination {
+				t.Errorf("%q: expected destination %q, got %q", tt.input, tt.destination, link.Destination)
+			}
+			assertContent(t, assertChild(t, link, 0, ast.NodeContent), tt.text)
+		}
+		if !found {
+			t.Errorf("%q: expected a link", tt.input)
+		}
+	}
+
+	for _, input := range []string{"www.a_b.com", "http://localhost", "awww.example.com", "`www.example.com`", "[www.example.com](/x)"} {
+		doc := gfm.Parse(input)
+		for link := range ast.OfType[*ast.Link](ast.All(doc)) {
+			if link.Destination != "/x" {
+				t.Errorf("%q: unexpected link %q", input, link.Destination)
+			}
+		}
+	}
+}
+
+func TestAutolinkPosition(t *testing.T) {
+	doc := gfm.Parse("go to www.example.com/a_b_c now")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 3)
+	assertContent(t, assertChild(t, p, 0, ast.NodeContent), "go to ")
+	link := assertChild(t, p, 1, ast.NodeLink)
+	assertPos(t, link, "1:7-1:27")
+	assertContent(t, assertChild(t, p, 2, ast.NodeContent), " now")
+}
@@ -1,15 +1,25 @@
 package parser
 
 import (
-    "markee/internal/ast"
+    "github.com/rybkr/markee/ast"
+    "sort"
     "strings"
 )
 
 type InlineParser struct {
-	input     string
-	pos       int
-	delims    *DelimiterStack
-	container ast.Node
+	input       string
+	pos         int
+	delims      *DelimiterStack
+	container   ast.Node
+	triggers    map[byte][]InlineFunc
+	segments    []lineSegment
+	diagnostics *diagnostics
+}
+
+// lineSegment records where a line of inline input starts in the source.
+type lineSegment struct {
+	at    int // offset of the line in the inline input
+	start ast.Point
 }
 
 func NewInlineParser(container ast.Node, content string) *InlineParser {
@@ -26,9 +36,121 @@ func ParseInlines(container ast.Node, content string) {
     ip.parse()
 }
 
+// Input returns the text being parsed.
+func (p *InlineParser) Input() string {
+	return p.input
+}
+
+// Pos returns the offset of the next unparsed byte in Input.
+func (p *InlineParser) Pos() int {
+	return p.pos
+}
+
+// Advance moves the position n bytes forward.
+func (p *InlineParser) Advance(n int) {
+	p.pos = min(p.pos+n, len(p.input))
+}
+
+// Peek returns the byte offset bytes past the position, or 0 past the end.
+func (p *InlineParser) Peek(offset int) byte {
+	return p.peek(offset)
+}
+
+// Container returns the node inline nodes are added to. Inside link text it
+// is still the paragraph or heading: nodes are moved into the link once its
+// closing bracket is found.
+func (p *InlineParser) Container() ast.Node {
+	return p.container
+}
+
+// Delimiters returns the stack of emphasis runs and brackets that are still
+// waiting for a match.
+func (p *InlineParser) Delimiters() *DelimiterStack {
+	return p.delims
+}
+
+// AddText appends literal text to the container.
+func (p *InlineParser) AddText(s string) {
+	p.container.AddChild(ast.NewContent(s))
+}
+
+// Position returns the source position of Input()[start:end]. It is the
+// zero Position when the parser was not given source locations.
+func (p *InlineParser) Position(start, end int) ast.Position {
+	if len(p.segments) == 0 {
+		return ast.Position{}
+	}
+	return ast.Position{Start: p.point(start, false), End: p.point(end, true)}
+}
+
+// point maps an input offset to the source. An end offset at the start of a
+// line belongs to the end of the line before it.
+func (p *InlineParser) point(offset int, isEnd bool) ast.Point {
+	i := sort.Search(len(p.segments), func(i int) bool {
+		if isEnd {
+			return p.segments[i].at >= offset
+		}
+		return p.segments[i].at > offset
+	}) - 1
+	seg := p.segments[max(i, 0)]
+	delta := offset - seg.at
+	return ast.Point{Line: seg.start.Line, Column: seg.start.Column + delta, Offset: seg.start.Offset + delta}
+}
+
+// tryTrigger runs the inline triggers registered for c until one matches,
+// rolling back the position, the nodes and the delimiters after each one
+// that does not. Nodes the trigger adds without a position are given the
+// span it consumed.
+func (p *InlineParser) tryTrigger(c byte) bool {
+	start := p.pos
+	last := p.container.LastChild()
+	top := p.delims.Top
+	for _, fn := range p.triggers[c] {
+		if fn(p) && p.pos > start {
+			p.fillPositions(last, p.Position(start, p.pos))
+			return true
+		}
+		p.pos = start
+		p.removeAfter(last)
+		p.delims.RemoveAbove(top)
+	}
+	return false
+}
+
+// removeAfter removes the children of the container after last.
+func (p *InlineParser) removeAfter(last ast.Node) {
+	next := p.container.FirstChild()
+	if last != nil {
+		next = last.NextSibling()
+	}
+	for next != nil {
+		node := next
+		next = next.NextSibling()
+		p.container.RemoveChild(node)
+	}
+}
+
+// fillPositions sets pos on the nodes after last that have no position.
+func (p *InlineParser) fillPositions(last ast.Node, pos ast.Position) {
+	next := p.container.FirstChild()
+	if last != nil {
+		next = last.NextSibling()
+	}
+	for ; next != nil; next = next.NextSibling() {
+		for node := range ast.All(next) {
+			if !node.Pos().IsValid() {
+				node.SetPos(pos)
+			}
+		}
+	}
+}
+
 func (p *InlineParser) parse() {
 	for p.pos < len(p.input) {
 		c := p.input[p.pos]
+		if p.tryTrigger(c) {
+			continue
+		}
 		switch c {
 		case '\n':
 			p.parseLineBreak()
@@ -44,7 +166,7 @@ func (p *InlineParser) parse() {
 			if p.peek(1) == '[' {
 				p.parseOpenImage()
 			} else {
-				p.addText(string(c))
+				p.addText(string(c), p.pos, p.pos+1)
 				p.pos++
 			}
 		case ']':
@@ -64,26 +186,32 @@ func (p *InlineParser) peek(offset int) byte {
 	return p.input[pos]
 }
 
-func (p *InlineParser) addText(s string) {
-	textNode := ast.NewContent(s)
-	p.container.AddChild(textNode)
+func (p *InlineParser) addText(s string, start, end int) {
+	p.addNode(ast.NewContent(s), start, end)
+}
+
+// addNode adds node to the container, placed at input[start:end].
+func (p *InlineParser) addNode(node ast.Node, start, end int) {
+	node.SetPos(p.Position(start, end))
+	p.container.AddChild(node)
 }
 
 func (p *InlineParser) parseText() {
 	start := p.pos
 
-	// Consume until we hit a special character
+	// Consume until we hit a special character. The first byte is always
+	// text, which may be a trigger byte whose syntax did not match.
 	for p.pos < len(p.input) {
 		c := p.input[p.pos]
-		if c == '\n' || c == '\\' || c == '`' || c == '*' || c == '_' ||
-			c == '[' || c == ']' || c == '!' {
+		if p.pos > start && (c == '\n' || c == '\\' || c == '`' || c == '*' || c == '_' ||
+			c == '[' || c == ']' || c == '!' || len(p.triggers[c]) > 0) {
 			break
 		}
 		p.pos++
 	}
 
 	if p.pos > start {
-		p.addText(p.input[start:p.pos])
+		p.addText(p.input[start:p.pos], start, p.pos)
 	}
 }
 
@@ -104,14 +232,14 @@ func (p *InlineParser) parseLineBreak() {
 		if spaces >= 2 {
 			// Hard break: remove trailing spaces, add hard break
 			textNode.Literal = strings.TrimRight(literal, " ")
-			p.container.AddChild(ast.NewLineBreak())
+			p.addNode(ast.NewLineBreak(), p.pos-1, p.pos)
 		} else {
 			// Soft break
-			p.container.AddChild(ast.NewSoftBreak())
+			p.addNode(ast.NewSoftBreak(), p.pos-1, p.pos)
 		}
 	} else {
 		// No previous text, just soft break
-		p.container.AddChild(ast.NewSoftBreak())
+		p.addNode(ast.NewSoftBreak(), p.pos-1, p.pos)
 	}
 }
 
@@ -123,19 +251,19 @@ func (p *InlineParser) parseBackslashEscape() {
 
 		// Check if it's an escapable character
 		if isEscapable(next) {
-			p.addText(string(next))
+			p.addText(string(next), p.pos-1, p.pos+1)
 			p.pos++
 		} else if next == '\n' {
 			// Backslash before newline = hard break
-			p.container.AddChild(ast.NewLineBreak())
+			p.addNode(ast.NewLineBreak(), p.pos-1, p.pos+1)
 			p.pos++
 		} else {
 			// Not escapable, add literal backslash
-			p.addText("\\")
+			p.addText("\\", p.pos-1, p.pos)
 		}
 	} else {
 		// Backslash at end of input
-		p.addText("\\")
+		p.addText("\\", p.pos-1, p.pos)
 	}
 }
 
@@ -178,7 +306,7 @@ func (p *InlineParser) parseBackticks() {
 
 				code = collapseWhitespace(code)
 
-				p.container.AddChild(ast.NewCodeSpan(code))
+				p.addNode(ast.NewCodeSpan(code), start, p.pos)
 				return
 			}
 		} else {
@@ -188,7 +316,7 @@ func (p *InlineParser) parseBackticks() {
 
 	// No matching close found, add as literal text
 	p.pos = start
-	p.addText(strings.Repeat("`", openTicks))
+	p.addText(strings.Repeat("`", openTicks), start, start+openTicks)
 	p.pos += openTicks
 }
 
@@ -226,7 +354,7 @@ func (p *InlineParser) parseDelimiterRun(char byte) {
 
 	// Add text node with the delimiter characters
 	textNode := ast.NewContent(strings.Repeat(string(char), count))
-	p.container.AddChild(textNode)
+	p.addNode(textNode, start, p.pos)
 
 	// Add to delimiter stack if it can open or close
 	if canOpen || canClose {
@@ -243,6 +371,7 @@ func (p *InlineParser) parseDelimiterRun(char byte) {
 			CanOpen:       canOpen,
 			CanClose:      canClose,
 			ContentNode:      textNode,
+			Start:         start,
 		}
 
 		p.delims.Push(delim)
@@ -308,7 +437,7 @@ func isPunctuation(r rune) bool {
 func (p *InlineParser) parseOpenBracket() {
 	// Add text node with '['
 	textNode := ast.NewContent("[")
-	p.container.AddChild(textNode)
+	p.addNode(textNode, p.pos, p.pos+1)
 
 	// Add to delimiter stack
 	delim := &Delimiter{
@@ -319,6 +448,7 @@ func (p *InlineParser) parseOpenBracket() {
 		CanOpen:       true,
 		CanClose:      false,
 		ContentNode:      textNode,
+		Start:         p.pos,
 	}
 
 	p.delims.Push(delim)
@@ -328,7 +458,7 @@ func (p *InlineParser) parseOpenBracket() {
 func (p *InlineParser) parseOpenImage() {
 	// Add text node with '!['
 	textNode := ast.NewContent("![")
-	p.container.AddChild(textNode)
+	p.addNode(textNode, p.pos, p.pos+2)
 
 	// Add to delimiter stack
 	delim := &Delimiter{
@@ -339,6 +469,7 @@ func (p *InlineParser) parseOpenImage() {
 		CanOpen:       true,
 		CanClose:      false,
 		ContentNode:      textNode,
+		Start:         p.pos,
 	}
 
 	p.delims.Push(delim)
@@ -346,6 +477,7 @@ func (p *InlineParser) parseOpenImage() {
 }
 
 func (p *InlineParser) parseCloseBracket() {
+	closeAt := p.pos
 	p.pos++ // consume ']'
 
 	// Look for link or image
@@ -353,14 +485,14 @@ func (p *InlineParser) parseCloseBracket() {
 
 	if opener == nil {
 		// No opener found, add literal ]
-		p.addText("]")
+		p.addText("]", closeAt, closeAt+1)
 		return
 	}
 
 	if !opener.IsActive {
 		// Opener is inactive, remove it and add literal ]
 		p.delims.Remove(opener)
-		p.addText("]")
+		p.addText("]", closeAt, closeAt+1)
 		return
 	}
 
@@ -376,17 +508,21 @@ func (p *InlineParser) parseCloseBracket() {
 	}
 
 	// Try reference link: ][ref] or ]
-	if _, ok := p.parseLinkLabel(); ok {
+	if label, ok := p.parseLinkLabel(); ok {
+		if strings.TrimSpace(label) == "" {
+			label = p.input[opener.Start+len(opener.ContentNode.Literal) : closeAt]
+		}
+		p.diagnostics.addReference(label, p.Position(opener.Start, p.pos))
 		// TODO: Look up reference in reference map
 		// For now, just remove opener and add literal ]
 		p.delims.Remove(opener)
-		p.addText("]")
+		p.addText("]", closeAt, closeAt+1)
 		return
 	}
 
 	// No valid link, remove opener and add literal ]
 	p.delims.Remove(opener)
-	p.addText("]")
+	p.addText("]", closeAt, closeAt+1)
 }
 
 func (p *InlineParser) findLinkOpener() *Delimiter {
@@ -459,13 +595,18 @@ func (p *InlineParser) parseAngleBracketDest() (string, bool) {
 	for p.pos < len(p.input) {
 		c := p.input[p.pos]
 
+		if c == '\\' && p.pos+1 < len(p.input) && isEscapable(p.input[p.pos+1]) {
+			p.pos += 2
+			continue
+		}
+
 		if c == '>' {
-			dest := p.input[start:p.pos]
+			dest := unescapeBackslashes(p.input[start:p.pos])
 			p.pos++ // consume '>'
 			return dest, true
 		}
 
-		if c == '<' || c == '\n' || c == '\\' {
+		if c == '<' || c == '\n' {
 			return "", false
 		}
 
@@ -511,7 +652,7 @@ func (p *InlineParser) parseRegularDest() (string, bool) {
 		return "", false
 	}
 
-	return dest, true
+	return unescapeBackslashes(dest), true
 }
 
 func (p *InlineParser) parseLinkTitle() (string, bool) {
@@ -535,7 +676,7 @@ func (p *InlineParser) parseLinkTitle() (string, bool) {
 		}
 
 		if c == closingDelim {
-			title := p.input[start:p.pos]
+			title := unescapeBackslashes(p.input[start:p.pos])
 			p.pos++ // consume closing delimiter
 			return title, true
 		}
@@ -546,6 +687,22 @@ func (p *InlineParser) parseLinkTitle() (string, bool) {
 	return "", false
 }
 
+// unescapeBackslashes removes the backslash from each backslash escape in a
+// link destination or title.
+func unescapeBackslashes(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isEscapable(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
 func (p *InlineParser) parseLinkLabel() (string, bool) {
 	if p.peek(0) != '[' {
 		return "", false
@@ -616,22 +773,19 @@ func (p *InlineParser) createLinkOrImage(opener *Delimiter, dest, title string,
 		linkNode = ast.NewImage(dest, title, altText)
 	} else {
 		linkNode = ast.NewLink(dest, title)
+	}
 
-		// Add inline nodes as children
-		for _, node := range inlineNodes {
+	// Move the nodes between opener and closer out of the container, into
+	// the link. An image keeps only their text.
+	for _, node := range inlineNodes {
+		p.container.RemoveChild(node)
+		if !isImage {
 			linkNode.AddChild(node)
 		}
 	}
 
-	// Remove nodes between opener and closer from container
-	current = openerNode.NextSibling()
-	for current != nil {
-		next := current.NextSibling()
-		p.container.RemoveChild(current)
-		current = next
-	}
-
 	// Replace opener text node with link/image node
+	linkNode.SetPos(p.Position(opener.Start, p.pos))
 	p.container.ReplaceChild(openerNode, linkNode)
 
 	// Process emphasis on the link content
@@ -720,6 +874,11 @@ func (p *InlineParser) processEmphasis(stackBottom *Delimiter) {
                 emphNode = ast.NewEmphasis()
             }
             
+            // The opener gives up its innermost (rightmost) delimiters and
+            // the closer its leftmost.
+            closerStart := currentPosition.Start + currentPosition.OriginalCount - currentPosition.Count
+            emphNode.SetPos(p.Position(opener.Start+opener.Count-useCount, closerStart+useCount))
+
             // Move nodes between opener and closer into emphasis node
             p.moveNodesBetween(opener.ContentNode, currentPosition.ContentNode, emphNode)
             
@@ -739,6 +898,7 @@ func (p *InlineParser) processEmphasis(stackBottom *Delimiter) {
                     string(opener.ContentNode.Literal[0]), 
                     opener.Count,
                 )
+                opener.ContentNode.SetPos(p.Position(opener.Start, opener.Start+opener.Count))
             } else {
                 // Remove empty opener
                 p.container.RemoveChild(opener.ContentNode)
@@ -750,6 +910,8 @@ func (p *InlineParser) processEmphasis(stackBottom *Delimiter) {
                     string(currentPosition.ContentNode.Literal[0]), 
                     currentPosition.Count,
                 )
+                closerEnd := currentPosition.Start + currentPosition.OriginalCount
+                currentPosition.ContentNode.SetPos(p.Position(closerEnd-currentPosition.Count, closerEnd))
             } else {
                 // Remove empty closer and advance
                 next := currentPosition.Next
@@ -781,6 +943,7 @@ func (p *InlineParser) processEmphasis(stackBottom *Delimiter) {
             // If closer is not a potential opener, remove it
             if !currentPosition.CanOpen {
                 next := currentPosition.Next
+                p.reportUnmatched(currentPosition)
                 p.delims.Remove(currentPosition)
                 currentPosition = next
             } else {
@@ -790,9 +953,20 @@ func (p *InlineParser) processEmphasis(stackBottom *Delimiter) {
     }
     
     // Remove all delimiters above stack_bottom
+    for d := p.delims.Top; d != nil && d != stackBottom; d = d.Prev {
+        p.reportUnmatched(d)
+    }
     p.delims.RemoveAbove(stackBottom)
 }
 
+// reportUnmatched reports what is left of an emphasis run as unmatched.
+func (p *InlineParser) reportUnmatched(d *Delimiter) {
+    if (d.Type == DelimiterAsterisk || d.Type == DelimiterUnderscore) && d.Count > 0 {
+        p.diagnostics.report(d.ContentNode.Pos(), SeverityInfo, CodeUnmatchedEmphasis,
+            "%q does not open or close emphasis", d.ContentNode.Literal)
+    }
+}
+
 func (p *InlineParser) findMatchingOpener(
     closer *Delimiter, 
     stackBottom *Delimiter,
@@ -856,6 +1030,7 @@ func (p *InlineParser) removeDelimitersBetween(start, end *Delimiter) {
     
     for current != nil && current != end {
         next := current.Next
+        p.reportUnmatched(current)
         p.delims.Remove(current)
         current = next
     }
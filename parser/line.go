@@ -1,11 +1,18 @@
 package parser
 
+import "github.com/rybkr/markee/ast"
+
+// Line is one line of input. Content is the part not yet consumed by block
+// markers, starting at byte Offset of Literal. Number and Start locate the
+// line in the whole input; they are zero for lines made by NewLine alone.
 type Line struct {
 	Literal string
 	Content string
 	Indent  int
 	Offset  int
 	IsBlank bool
+	Number  int
+	Start   int
 }
 
 func NewLine(raw string) *Line {
@@ -35,6 +42,12 @@ func NewLine(raw string) *Line {
 	}
 }
 
+// Point returns the source location of byte offset of Literal.
+func (l *Line) Point(offset int) ast.Point {
+	offset = min(offset, len(l.Literal))
+	return ast.Point{Line: l.Number, Column: offset + 1, Offset: l.Start + offset}
+}
+
 func (l *Line) Consume(n int) {
 	if n > len(l.Content) {
 		n = len(l.Content)
@@ -0,0 +1,325 @@
+package parser
+
+import (
+	"github.com/rybkr/markee/ast"
+	"regexp"
+	"strings"
+)
+
+// BlockMatcher tries to start a new block at the current position of a line.
+// Match returns nil when the line does not open the block, and must only
+// consume from the line when it succeeds. CanInterrupt reports whether the
+// block may start while the given node is the tip, e.g. indented code cannot
+// interrupt a paragraph. Matchers are tried in ascending Priority order.
+type BlockMatcher struct {
+	Priority     int
+	Name         string
+	Match        func(*Line) ast.Node
+	CanInterrupt func(ast.Node) bool
+}
+
+// coreBlockMatchers recognize the CommonMark block syntax. Priorities are
+// spaced out so extensions can slot their matchers in between.
+var coreBlockMatchers = []BlockMatcher{
+	{Priority: 10, Name: "thematic_break", Match: matchThematicBreak, CanInterrupt: AlwaysInterrupt},
+	{Priority: 20, Name: "atx_heading", Match: matchATXHeading, CanInterrupt: AlwaysInterrupt},
+	{Priority: 30, Name: "fenced_code", Match: matchFencedCodeBlock, CanInterrupt: AlwaysInterrupt},
+	{Priority: 50, Name: "block_quote", Match: matchBlockQuote, CanInterrupt: AlwaysInterrupt},
+	{Priority: 60, Name: "indented_code", Match: matchIndentedCodeBlock, CanInterrupt: CannotInterruptParagraph},
+	{Priority: 100, Name: "paragraph", Match: matchParagraph, CanInterrupt: AlwaysInterrupt},
+}
+
+// AlwaysInterrupt lets a block start regardless of the tip.
+func AlwaysInterrupt(n ast.Node) bool { return true }
+
+// CannotInterruptParagraph keeps a block from starting inside an open
+// paragraph, where the line is a lazy continuation instead.
+func CannotInterruptParagraph(n ast.Node) bool {
+	_, isParagraph := n.(*ast.Paragraph)
+	return !isParagraph
+}
+
+func (p *Parser) matchNewBlock(line *Line, currentTip ast.Node) ast.Node {
+	if currentTip.Type() == ast.NodeCodeBlock {
+		if fence := matchFencedCodeBlock(line); fence != nil {
+			if fence.(*ast.CodeBlock).FenceChar == currentTip.(*ast.CodeBlock).FenceChar {
+				line.ConsumeAll()
+				currentTip.SetOpen(false)
+				return nil
+			}
+		}
+		// The indent is part of the code, so it starts the content again.
+		line.Content = strings.Repeat(" ", line.Indent) + line.Content
+		line.Offset = max(line.Offset-line.Indent, 0)
+		return nil
+	}
+
+	for _, matcher := range p.blockMatchers {
+		if !matcher.CanInterrupt(currentTip) {
+			continue
+		}
+		saved := *line
+		if block := matcher.Match(line); block != nil {
+			if block.Type() == ast.NodeParagraph && currentTip.Type() == ast.NodeParagraph {
+				return nil
+			}
+			if block.Type().IsContainer() && !p.canNest(currentTip) {
+				*line = saved
+				continue
+			}
+			start := saved.Point(saved.Offset)
+			block.SetPos(ast.Position{Start: start, End: start})
+			return block
+		}
+	}
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#thematic-breaks
+func matchThematicBreak(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	reAsterisk := regexp.MustCompile(`^[* \t]*\*[* \t]*\*[* \t]*\*[* \t]*$`)
+	reHyphen := regexp.MustCompile(`^[\- \t]*-[\- \t]*-[\- \t]*-[\- \t]*$`)
+	reUnderscore := regexp.MustCompile(`^[_ \t]*_[_ \t]*_[_ \t]*_[_ \t]*$`)
+
+	if reAsterisk.MatchString(line.Content) || reHyphen.MatchString(line.Content) || reUnderscore.MatchString(line.Content) {
+		line.ConsumeAll()
+		return ast.NewThematicBreak()
+	}
+
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#atx-headings
+func matchATXHeading(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	// ^(#{1,6}) : 1-6 '#' characters
+	// [ \t]*    : optional spaces/tabs
+	// (?:#*)?   : optional trailing hashes (no space required when empty)
+	// [ \t]*$   : optional trailing whitespace
+	reATXHeadingEmpty := regexp.MustCompile(`^(#{1,6})[ \t]*(?:#*)?[ \t]*$`)
+
+	if matches := reATXHeadingEmpty.FindStringSubmatch(line.Content); matches != nil {
+		level := len(matches[1])
+		line.ConsumeAll()
+		return ast.NewHeading(level)
+	}
+
+	//      (#{1,6}) : opening sequence of 1-6 '#' characters
+	//        [ \t]+ : space/tab after '#' sequence, needed if heading has content
+	//      ([^#]*?) : capture content lazily as to not eat trailing hashes
+	// (?:[ \t]+#*)? : optional closing '#' sequence, preceded by as least one space/tab
+	//        [ \t]* : optional space/tab at the end
+	reATXHeadingWithContent := regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#*)?[ \t]*$`)
+
+	if matches := reATXHeadingWithContent.FindStringSubmatch(line.Content); matches != nil {
+		level := len(matches[1])
+		line.Consume(len(matches[1]))
+		line.ConsumeWhitespace()
+		line.KeepUntil(len(matches[2]))
+		return ast.NewHeading(level)
+	}
+
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#block-quotes
+func matchBlockQuote(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+	if line.Peek(0) == '>' {
+		line.Consume(1)
+		if line.Peek(0) == ' ' {
+			line.Consume(1)
+		}
+		return ast.NewBlockQuote()
+	}
+	return nil
+}
+
+// matchTOC recognizes a table of contents placeholder on a line of its own,
+// either [TOC] or <!-- toc -->.
+func matchTOC(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	reTOC := regexp.MustCompile(`^(?:\[TOC\]|(?i:<!--[ \t]*toc[ \t]*-->))[ \t]*$`)
+
+	if reTOC.MatchString(line.Content) {
+		line.ConsumeAll()
+		return ast.NewTOC()
+	}
+	return nil
+}
+
+// matchAbbreviationDefinition recognizes a PHP Markdown Extra abbreviation
+// definition such as *[HTML]: Hyper Text Markup Language.
+func matchAbbreviationDefinition(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	// ^\*\[([^\[\]]+)\]: : label in brackets after an asterisk, then a colon
+	// [ \t]*(.*?)[ \t]*$  : title, which may be empty
+	reAbbr := regexp.MustCompile(`^\*\[([^\[\]]+)\]:[ \t]*(.*?)[ \t]*$`)
+
+	if matches := reAbbr.FindStringSubmatch(line.Content); matches != nil {
+		label := strings.TrimSpace(matches[1])
+		if label == "" {
+			return nil
+		}
+		line.ConsumeAll()
+		return ast.NewAbbreviationDefinition(label, matches[2])
+	}
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#paragraphs
+func matchParagraph(line *Line) ast.Node {
+	if !line.IsBlank && !line.IsEmpty() {
+		return ast.NewParagraph()
+	}
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#indented-code-blocks
+func matchIndentedCodeBlock(line *Line) ast.Node {
+	if line.Indent >= 4 {
+        line.Consume(4)
+		return ast.NewCodeBlock(false)
+	}
+	return nil
+}
+
+// See: https://spec.commonmark.org/0.31.2/#fenced-code-blocks
+func matchFencedCodeBlock(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	// ^(`{3,}|~{3,}) : at least 3 backticks or tildes
+	// [ \t]*         : optional spaces/tabs
+	// (.*?)          : info string
+	// [ \t]*$        : optional trailing whitespace
+	reFence := regexp.MustCompile("^(`{3,}|~{3,})[ \\t]*(.*?)[ \\t]*$")
+
+	if matches := reFence.FindStringSubmatch(line.Content); matches != nil {
+		fenceStr := matches[1]
+		infoString := matches[2]
+
+		// The info string of a backtick fence may not contain backticks.
+		if fenceStr[0] == '`' && strings.ContainsRune(infoString, '`') {
+			return nil
+		}
+
+		codeBlock := ast.NewCodeBlock(true)
+		codeBlock.FenceChar = fenceStr[0]
+        codeBlock.FenceLen = len(fenceStr)
+		codeBlock.Info = infoString
+		codeBlock.Language, codeBlock.Attributes = parseInfoString(infoString)
+
+		line.ConsumeAll()
+		return codeBlock
+	}
+	return nil
+}
+
+// parseInfoString splits a fenced code block's info string into the language,
+// its first word, and the attribute list that may follow it:
+//
+//	go {linenos=true hl_lines="2-4" title="main.go"}
+//
+// The Pandoc form {.go linenos=true} is accepted too, taking the language from
+// the first class. Anything else after the language is ignored.
+func parseInfoString(info string) (string, []ast.Attribute) {
+	if strings.HasPrefix(info, "{") {
+		attrs, ok := parseAttributes(info)
+		if !ok {
+			return "", nil
+		}
+		for i, attr := range attrs {
+			if attr.Key == "class" {
+				return attr.Value, append(attrs[:i:i], attrs[i+1:]...)
+			}
+		}
+		return "", attrs
+	}
+
+	language, rest := info, ""
+	if i := strings.IndexAny(info, " \t"); i >= 0 {
+		language, rest = info[:i], strings.TrimSpace(info[i:])
+	}
+	if !strings.HasPrefix(rest, "{") {
+		return language, nil
+	}
+	attrs, ok := parseAttributes(rest)
+	if !ok {
+		return language, nil
+	}
+	return language, attrs
+}
+
+// matchFencedDiv opens a custom container: a fence of at least three colons,
+// then a name, an attribute list, or both.
+func matchFencedDiv(line *Line) ast.Node {
+	if line.Indent >= 4 {
+		return nil
+	}
+
+	// ^(:{3,})                  : at least 3 colons
+	// [ \t]*([A-Za-z][\w-]*)?   : optional container name
+	// [ \t]*(\{[^{}]*\})?        : optional attribute list
+	// [ \t]*$                   : optional trailing whitespace
+	reDiv := regexp.MustCompile(`^(:{3,})[ \t]*([A-Za-z][\w-]*)?[ \t]*(\{[^{}]*\})?[ \t]*$`)
+
+	matches := reDiv.FindStringSubmatch(line.Content)
+	if matches == nil || (matches[2] == "" && matches[3] == "") {
+		return nil
+	}
+
+	div := ast.NewFencedDiv(matches[2], len(matches[1]))
+	if matches[3] != "" {
+		attrs, ok := parseAttributes(matches[3])
+		if !ok {
+			return nil
+		}
+		div.Attributes = attrs
+	}
+
+	line.ConsumeAll()
+	return div
+}
+
+// See: https://spec.commonmark.org/0.31.2/#setext-headings
+func matchSetextHeadingUnderline(line *Line, currentTip ast.Node) int {
+    // Only check if we're in an open paragraph
+    if currentTip.Type() != ast.NodeParagraph || !currentTip.IsOpen() {
+        return 0
+    }
+    
+    if line.Indent >= 4 {
+        return 0
+    }
+    
+    // Check for = underline (level 1)
+    reEquals := regexp.MustCompile(`^=+[ \t]*$`)
+    if reEquals.MatchString(line.Content) {
+        return 1
+    }
+    
+    // Check for - underline (level 2)
+    reHyphens := regexp.MustCompile(`^-+[ \t]*$`)
+    if reHyphens.MatchString(line.Content) {
+        return 2
+    }
+    
+    return 0
+}
@@ -0,0 +1,346 @@
+// Package parser turns CommonMark text into an ast.Document. A Parser is
+// built once with New, choosing the syntax extensions it recognizes, and can
+// then be used from several goroutines.
+package parser
+
+import (
+	"github.com/rybkr/markee/ast"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Parser turns Markdown into a document tree. Its syntax is fixed by New, so a
+// Parser can be shared by several goroutines, and Parsers with different
+// extensions can be used side by side.
+type Parser struct {
+	blockMatchers  []BlockMatcher
+	inlineTriggers map[byte][]InlineFunc
+	transforms     []Transform
+	limits         Limits
+	lossless       bool
+	debug          func(*TransformError)
+}
+
+// Limits bounds the work done on hostile input. A zero field means no limit.
+type Limits struct {
+	// MaxNesting is the deepest container blocks (block quotes, lists,
+	// fenced divs) may nest. Markers past the limit are read as text.
+	MaxNesting int
+}
+
+type config struct {
+	extensions []Extension
+	limits     Limits
+	lossless   bool
+	debug      func(*TransformError)
+}
+
+// Option configures a Parser.
+type Option func(*config)
+
+// WithExtensions enables extensions in addition to those already enabled.
+func WithExtensions(extensions ...Extension) Option {
+	return func(c *config) {
+		c.extensions = append(c.extensions, extensions...)
+	}
+}
+
+// WithoutExtensions disables the named extensions.
+func WithoutExtensions(names ...string) Option {
+	return func(c *config) {
+		kept := c.extensions[:0:0]
+		for _, extension := range c.extensions {
+			if !slices.Contains(names, extension.Name()) {
+				kept = append(kept, extension)
+			}
+		}
+		c.extensions = kept
+	}
+}
+
+// WithCommonMark disables every extension enabled so far, leaving plain
+// CommonMark. Later options may enable extensions again.
+func WithCommonMark() Option {
+	return func(c *config) {
+		c.extensions = nil
+	}
+}
+
+// WithGFM replaces every extension enabled so far with the GitHub Flavored
+// Markdown ones, GFMExtensions. Later options may enable others again.
+func WithGFM() Option {
+	return func(c *config) {
+		c.extensions = GFMExtensions()
+	}
+}
+
+// WithLimits sets the limits applied while parsing.
+func WithLimits(limits Limits) Option {
+	return func(c *config) {
+		c.limits = limits
+	}
+}
+
+// WithLossless makes the parser take an ast.Snapshot of every document it
+// returns, keeping the source text each node was parsed from. A Markdown
+// renderer with renderer.WithLossless then prints an unmodified document
+// exactly as it was written, and changes only the text of edited nodes.
+func WithLossless() Option {
+	return func(c *config) {
+		c.lossless = true
+	}
+}
+
+// WithDebug makes the parser check the document with ast.Validate after each
+// transform, and call report with a TransformError if the transform made the
+// tree invalid. It is meant for developing extensions.
+func WithDebug(report func(err *TransformError)) Option {
+	return func(c *config) {
+		c.debug = report
+	}
+}
+
+// TransformError lists the problems a transform brought into a document.
+// Problems the document already had before the transform ran are left out,
+// even when the transform moved the nodes that have them.
+type TransformError struct {
+	Transform string
+	Errors    ast.ValidationErrors
+}
+
+func (e *TransformError) Error() string {
+	return "parser: transform " + e.Transform + " made the document invalid: " + e.Errors.Error()
+}
+
+// New returns a Parser with the DefaultExtensions, adjusted by opts.
+func New(opts ...Option) *Parser {
+	c := &config{extensions: DefaultExtensions()}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r := newRegistry()
+	var applied []string
+	for _, extension := range c.extensions {
+		if slices.Contains(applied, extension.Name()) {
+			continue
+		}
+		extension.Extend(r)
+		applied = append(applied, extension.Name())
+	}
+	r.sort()
+
+	return &Parser{
+		blockMatchers:  r.blockMatchers,
+		inlineTriggers: r.inlineTriggers,
+		transforms:     r.transforms,
+		limits:         c.limits,
+		lossless:       c.lossless,
+		debug:          c.debug,
+	}
+}
+
+var defaultParser = New()
+
+// Parse parses input with the default extensions.
+func Parse(input string) *ast.Document {
+	return defaultParser.Parse(input)
+}
+
+// Parse is the main parsing entry point, turns raw strings into an AST. Line
+// endings, a byte order mark and NUL bytes are handled as for ParseReader.
+// See: https://spec.commonmark.org/0.31.2/#appendix-a-parsing-strategy
+func (p *Parser) Parse(input string) *ast.Document {
+	doc, _ := p.parse(strings.NewReader(input), nil) // reading a string cannot fail
+	return doc
+}
+
+// parse parses the input read from r, reporting suspicious input to d if it
+// is not nil.
+func (p *Parser) parse(r io.Reader, d *diagnostics) (*ast.Document, error) {
+	ctx := NewContext()
+	ctx.diagnostics = d
+
+	lines := newLineReader(r, p.lossless)
+	end := ast.Point{Line: 1, Column: 1}
+	for {
+		line, err := lines.next()
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			break
+		}
+		p.incorporateLine(ctx, line)
+		end = line.Point(len(line.Literal))
+	}
+
+	ctx.CloseUnmatchedBlocks(ctx.Doc)
+	ctx.Doc.SetPos(ast.Position{Start: ast.Point{Line: 1, Column: 1}, End: end})
+
+	finalizer := NewBlockFinalizer(p)
+	finalizer.diagnostics = d
+	ctx.Doc.Accept(finalizer)
+
+	var problems map[problem]bool
+	if p.debug != nil {
+		problems, _ = checkTransform(ctx.Doc, nil)
+	}
+	for _, transform := range p.transforms {
+		transform.Apply(ctx.Doc)
+		if p.debug != nil {
+			var introduced ast.ValidationErrors
+			problems, introduced = checkTransform(ctx.Doc, problems)
+			if len(introduced) > 0 {
+				p.debug(&TransformError{Transform: transform.Name, Errors: introduced})
+			}
+		}
+	}
+	if p.lossless {
+		ast.TakeSnapshot(ctx.Doc, lines.source.String())
+	}
+
+	return ctx.Doc, nil
+}
+
+// problem identifies a validation error by its node and message, which stay
+// the same when a transform moves the node.
+type problem struct {
+	node    ast.Node
+	message string
+}
+
+// checkTransform validates doc after a transform ran, returning its problems
+// and those of them that are not in before, the problems doc had before the
+// transform.
+func checkTransform(doc *ast.Document, before map[problem]bool) (map[problem]bool, ast.ValidationErrors) {
+	problems := make(map[problem]bool)
+	var introduced ast.ValidationErrors
+	errs, _ := ast.Validate(doc).(ast.ValidationErrors)
+	for _, err := range errs {
+		key := problem{err.Node, err.Message}
+		problems[key] = true
+		if before != nil && !before[key] {
+			introduced = append(introduced, err)
+		}
+	}
+	return problems, introduced
+}
+
+// extendBlocks moves the end of node and its ancestors to the end of line.
+func extendBlocks(node ast.Node, line *Line) {
+	end := line.Point(len(line.Literal))
+	for ; node != nil; node = node.Parent() {
+		pos := node.Pos()
+		pos.End = end
+		node.SetPos(pos)
+	}
+}
+
+// parseInlines is ParseInlines with the parser's inline triggers, placing
+// the inline nodes in the source by segments and reporting to d.
+func (p *Parser) parseInlines(container ast.Node, content string, segments []lineSegment, d *diagnostics) {
+	ip := NewInlineParser(container, content)
+	ip.triggers = p.inlineTriggers
+	ip.segments = segments
+	ip.diagnostics = d
+	ip.parse()
+}
+
+// canNest reports whether a new container block may open below tip.
+func (p *Parser) canNest(tip ast.Node) bool {
+	if p.limits.MaxNesting <= 0 {
+		return true
+	}
+	depth := 0
+	for node := tip; node != nil; node = node.Parent() {
+		if node.Type().IsContainer() && node.Type() != ast.NodeDocument {
+			depth++
+		}
+	}
+	return depth < p.limits.MaxNesting
+}
+
+// incorporateLine handles line-by-line block parsing logic.
+// See: https://spec.commonmark.org/0.31.2/#phase-1-block-structure
+func (p *Parser) incorporateLine(ctx *Context, line *Line) {
+	extender := NewBlockExtender(line)
+	ctx.Doc.Accept(extender)
+	lastMatched := extender.LastMatch()
+
+	// A closing fence may have closed the tip along with its container.
+	if !ctx.Tip.IsOpen() {
+		ctx.CloseUnmatchedBlocks(lastMatched)
+	}
+
+    if line.IsBlank {
+        if ctx.Tip.Type() == ast.NodeParagraph {
+            ctx.Tip.SetOpen(false)
+            ctx.SetTip(ctx.Tip.Parent())
+        }
+        ctx.SetTip(lastMatched)
+        // Trailing blank lines are not part of an indented code block.
+        if codeBlock, ok := lastMatched.(*ast.CodeBlock); !ok || codeBlock.IsFenced {
+            extendBlocks(lastMatched, line)
+        }
+        return
+    }
+
+	newBlock := p.matchNewBlock(line, ctx.Tip)
+
+	if newBlock != nil {
+		ctx.CloseUnmatchedBlocks(lastMatched)
+		// A new block ends the paragraph or other leaf it interrupts.
+		for !ctx.Tip.Type().IsContainer() {
+			ctx.Tip.SetOpen(false)
+			ctx.SetTip(ctx.Tip.Parent())
+		}
+		ctx.AddChild(newBlock)
+		ctx.SetTip(newBlock)
+
+		if !newBlock.Type().IsLeaf() {
+			for {
+				nextBlock := p.matchNewBlock(line, ctx.Tip)
+				if nextBlock == nil {
+					break
+				}
+				ctx.AddChild(nextBlock)
+				ctx.SetTip(nextBlock)
+
+				if nextBlock.Type().IsLeaf() {
+					break
+				}
+			}
+		}
+	} else {
+		if ctx.Tip.Type() == ast.NodeParagraph && lastMatched != ctx.Tip {
+			ctx.SetTip(ctx.Tip)
+		} else {
+			ctx.CloseUnmatchedBlocks(lastMatched)
+			ctx.SetTip(lastMatched)
+		}
+	}
+
+	if !line.IsEmpty() {
+		if ctx.Tip.Type() == ast.NodeCodeBlock {
+			content := ast.NewContent(line.Content)
+			content.SetPos(ast.Position{Start: line.Point(line.Offset), End: line.Point(line.Offset + len(line.Content))})
+			ctx.Tip.AddChild(content)
+		} else if ctx.Tip.Type() != ast.NodeDocument {
+			start := line.Offset + len(line.Content) - len(strings.TrimLeft(line.Content, " \t"))
+			content := ast.NewContent(strings.TrimSpace(line.Content))
+			content.SetPos(ast.Position{Start: line.Point(start), End: line.Point(start + len(content.Literal))})
+			ctx.Tip.AddChild(content)
+			if ctx.Tip.Type() == ast.NodeParagraph {
+				ctx.diagnostics.checkLine(content)
+			}
+		}
+	}
+
+	if closed := extender.Closed(); closed != nil {
+		extendBlocks(closed, line)
+	} else {
+		extendBlocks(ctx.Tip, line)
+	}
+}
@@ -0,0 +1,331 @@
+package parser
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// plusRule turns a line of +++ into a thematic break.
+type plusRule struct{}
+
+func (plusRule) Name() string { return "plus_rule" }
+
+func (plusRule) Extend(r *Registry) {
+	r.AddBlockMatcher(BlockMatcher{
+		Priority: 15,
+		Name:     "plus_rule",
+		Match: func(line *Line) ast.Node {
+			if strings.TrimSpace(line.Content) != "+++" {
+				return nil
+			}
+			line.ConsumeAll()
+			return ast.NewThematicBreak()
+		},
+	})
+}
+
+// mentions turns @name into a link to the user's profile.
+type mentions struct{}
+
+func (mentions) Name() string { return "mentions" }
+
+func (mentions) Extend(r *Registry) {
+	r.AddInlineTrigger('@', func(p *InlineParser) bool {
+		input, start := p.Input(), p.Pos()+1
+		end := start
+		for end < len(input) && (input[end] >= 'a' && input[end] <= 'z') {
+			end++
+		}
+		if end == start {
+			return false
+		}
+		link := ast.NewLink("/users/"+input[start:end], "")
+		link.AddChild(ast.NewContent(input[start-1 : end]))
+		p.Container().AddChild(link)
+		p.Advance(end - p.Pos())
+		return true
+	})
+}
+
+func TestParserCommonMarkDisablesExtensions(t *testing.T) {
+	input := "[TOC]\n\n::: note\nx\n:::"
+
+	doc := Parse(input)
+	assertChild(t, doc, 0, ast.NodeTOC)
+	assertChild(t, doc, 1, ast.NodeFencedDiv)
+
+	doc = New(WithCommonMark()).Parse(input)
+	for _, child := range doc.Children() {
+		assertNodeType(t, child, ast.NodeParagraph)
+	}
+}
+
+func TestParserWithoutExtensions(t *testing.T) {
+	input := "[TOC]\n\n*[HTML]: Hyper Text Markup Language"
+	doc := New(WithoutExtensions("toc")).Parse(input)
+	assertChildCount(t, doc, 2)
+	assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChild(t, doc, 1, ast.NodeAbbreviationDefinition)
+}
+
+func TestParserCustomBlockMatcher(t *testing.T) {
+	input := "foo\n\n+++"
+
+	doc := New(WithExtensions(plusRule{})).Parse(input)
+	assertChildCount(t, doc, 2)
+	assertChild(t, doc, 1, ast.NodeThematicBreak)
+
+	doc = Parse(input)
+	assertChild(t, doc, 1, ast.NodeParagraph)
+}
+
+func TestParserInlineTrigger(t *testing.T) {
+	doc := New(WithExtensions(mentions{})).Parse("hi @bob and @ you")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 4)
+	assertContent(t, assertChild(t, p, 0, ast.NodeContent), "hi ")
+	link := assertChild(t, p, 1, ast.NodeLink).(*ast.Link)
+	if link.Destination != "/users/bob" {
+		t.Errorf("unexpected destination %q", link.Destination)
+	}
+	assertContent(t, assertChild(t, p, 2, ast.NodeContent), " and ")
+	assertContent(t, assertChild(t, p, 3, ast.NodeContent), "@ you")
+}
+
+// halfMarker adds a node and an emphasis opener for %, then gives up.
+type halfMarker struct{}
+
+func (halfMarker) Name() string { return "half-marker" }
+
+func (halfMarker) Extend(r *Registry) {
+	r.AddInlineTrigger('%', func(p *InlineParser) bool {
+		text := ast.NewContent("*")
+		p.Container().AddChild(text)
+		p.Delimiters().Push(&Delimiter{
+			Type: DelimiterAsterisk, Count: 1, OriginalCount: 1,
+			IsActive: true, CanOpen: true, ContentNode: text, Start: p.Pos(),
+		})
+		p.Advance(1)
+		return false
+	})
+}
+
+func TestParserFailedTriggerRollsBack(t *testing.T) {
+	doc := New(WithExtensions(halfMarker{})).Parse("a %b*")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	var text strings.Builder
+	for _, child := range p.Children() {
+		assertNodeType(t, child, ast.NodeContent)
+		text.WriteString(child.(*ast.Content).Literal)
+	}
+	if text.String() != "a %b*" {
+		t.Errorf("expected the input as text, got %q", text.String())
+	}
+}
+
+func TestParseLinkKeepsFollowingText(t *testing.T) {
+	doc := Parse(`Some [a *link*](/x "a \"b\"") and __more__.`)
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 5)
+	link := assertChild(t, p, 1, ast.NodeLink).(*ast.Link)
+	if link.Title != `a "b"` {
+		t.Errorf("expected the title to be unescaped, got %q", link.Title)
+	}
+	assertChildCount(t, link, 2)
+	assertContent(t, assertChild(t, link, 0, ast.NodeContent), "a ")
+	assertChild(t, link, 1, ast.NodeEmphasis)
+	assertContent(t, assertChild(t, p, 2, ast.NodeContent), " and ")
+	assertChild(t, p, 3, ast.NodeStrong)
+	assertContent(t, assertChild(t, p, 4, ast.NodeContent), ".")
+}
+
+func TestParserTransform(t *testing.T) {
+	var calls []string
+	ext := extensionFunc{name: "counter", extend: func(r *Registry) {
+		r.AddTransform(Transform{Priority: 2, Name: "second", Apply: func(*ast.Document) { calls = append(calls, "second") }})
+		r.AddTransform(Transform{Priority: 1, Name: "first", Apply: func(*ast.Document) { calls = append(calls, "first") }})
+	}}
+
+	New(WithExtensions(ext)).Parse("foo")
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("unexpected transform order %v", calls)
+	}
+}
+
+func TestParserDebugValidatesTransforms(t *testing.T) {
+	wrap := extensionFunc{name: "wrap", extend: func(r *Registry) {
+		r.AddTransform(Transform{Priority: 1, Name: "wrap", Apply: func(doc *ast.Document) {
+			heading := doc.FirstChild()
+			doc.RemoveChild(heading)
+			paragraph := ast.NewParagraph()
+			paragraph.AddChild(heading)
+			doc.AddChild(paragraph)
+		}})
+	}}
+	var reported []*TransformError
+	report := func(err *TransformError) { reported = append(reported, err) }
+
+	// The abbreviation transform leaves a valid tree valid.
+	New(WithDebug(report)).Parse("# Title\n\nThe HTML spec.\n\n*[HTML]: HyperText Markup Language")
+	if len(reported) != 0 {
+		t.Errorf("expected no reports, got %v", reported)
+	}
+
+	New(WithExtensions(wrap), WithDebug(report)).Parse("# Title")
+	if len(reported) != 1 {
+		t.Fatalf("expected one report, got %v", reported)
+	}
+	err := reported[0]
+	if err.Transform != "wrap" || len(err.Errors) != 1 || err.Errors[0].Message != "paragraph cannot contain heading" {
+		t.Errorf("expected the report to name the transform and the problem, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "transform wrap") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestParserDebugIgnoresMovedProblems(t *testing.T) {
+	var broken *ast.Heading
+	breakTree := extensionFunc{name: "break", extend: func(r *Registry) {
+		r.AddTransform(Transform{Priority: 1, Name: "break", Apply: func(doc *ast.Document) {
+			broken = doc.FirstChild().(*ast.Heading)
+			broken.Level = 9
+		}})
+		// move places the broken heading last, changing its position.
+		r.AddTransform(Transform{Priority: 2, Name: "move", Apply: func(doc *ast.Document) {
+			doc.RemoveChild(broken)
+			doc.AddChild(broken)
+			broken.SetPos(ast.Position{})
+		}})
+	}}
+
+	var reported []string
+	New(WithExtensions(breakTree), WithDebug(func(err *TransformError) {
+		reported = append(reported, err.Transform)
+	})).Parse("# Title\n\ntext")
+	if strings.Join(reported, ",") != "break" {
+		t.Errorf("expected only the transform that broke the tree to be reported, got %v", reported)
+	}
+}
+
+func TestParserMaxNesting(t *testing.T) {
+	doc := New(WithLimits(Limits{MaxNesting: 2})).Parse("> > > foo")
+	outer := assertChild(t, doc, 0, ast.NodeBlockQuote)
+	inner := assertChild(t, outer, 0, ast.NodeBlockQuote)
+	assertChild(t, inner, 0, ast.NodeParagraph)
+}
+
+func TestParserConcurrentFeatureSets(t *testing.T) {
+	plain := New(WithCommonMark())
+	extended := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if got := plain.Parse("[TOC]").FirstChild().Type(); got != ast.NodeParagraph {
+				t.Errorf("expected paragraph, got %v", got)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if got := extended.Parse("[TOC]").FirstChild().Type(); got != ast.NodeTOC {
+				t.Errorf("expected TOC, got %v", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type extensionFunc struct {
+	name   string
+	extend func(r *Registry)
+}
+
+func (e extensionFunc) Name() string { return e.name }
+
+func (e extensionFunc) Extend(r *Registry) { e.extend(r) }
+
+// hashtags turns #word into a Tag link, but not inside link text, where the
+// link would end up nested.
+type hashtags struct{}
+
+func (hashtags) Triggers() []byte { return []byte{'#'} }
+
+func (hashtags) Parse(p *InlineParser) bool {
+	for d := p.Delimiters().Top; d != nil; d = d.Prev {
+		if d.Type == DelimiterOpenBracket && d.IsActive {
+			return false
+		}
+	}
+	input, start := p.Input(), p.Pos()+1
+	end := start
+	for end < len(input) && input[end] >= 'a' && input[end] <= 'z' {
+		end++
+	}
+	if end == start {
+		return false
+	}
+	link := ast.NewLink("/tags/"+input[start:end], "")
+	link.AddChild(ast.NewContent(input[start-1 : end]))
+	p.Container().AddChild(link)
+	p.Advance(end - p.Pos())
+	return true
+}
+
+// issues turns #123 into a link to the issue.
+type issues struct{}
+
+func (issues) Triggers() []byte { return []byte{'#'} }
+
+func (issues) Parse(p *InlineParser) bool {
+	if p.Peek(1) < '0' || p.Peek(1) > '9' {
+		return false
+	}
+	n := 1
+	for p.Peek(n) >= '0' && p.Peek(n) <= '9' {
+		n++
+	}
+	number := p.Input()[p.Pos()+1 : p.Pos()+n]
+	link := ast.NewLink("/issues/"+number, "")
+	link.AddChild(ast.NewContent("#" + number))
+	p.Container().AddChild(link)
+	p.Advance(n)
+	return true
+}
+
+func TestParserInlineSyntaxSharedTrigger(t *testing.T) {
+	ext := extensionFunc{name: "refs", extend: func(r *Registry) {
+		r.AddInlineSyntax(hashtags{})
+		r.AddInlineSyntax(issues{})
+	}}
+
+	doc := New(WithExtensions(ext)).Parse("#go fixes #42 # not")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 5)
+	if dest := assertChild(t, p, 0, ast.NodeLink).(*ast.Link).Destination; dest != "/tags/go" {
+		t.Errorf("unexpected destination %q", dest)
+	}
+	assertContent(t, assertChild(t, p, 1, ast.NodeContent), " fixes ")
+	if dest := assertChild(t, p, 2, ast.NodeLink).(*ast.Link).Destination; dest != "/issues/42" {
+		t.Errorf("unexpected destination %q", dest)
+	}
+	assertContent(t, assertChild(t, p, 3, ast.NodeContent), " ")
+	assertContent(t, assertChild(t, p, 4, ast.NodeContent), "# not")
+}
+
+func TestParserInlineSyntaxSeesDelimiters(t *testing.T) {
+	ext := extensionFunc{name: "hashtags", extend: func(r *Registry) {
+		r.AddInlineSyntax(hashtags{})
+	}}
+
+	doc := New(WithExtensions(ext)).Parse("[#go")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	for _, child := range p.Children() {
+		assertNodeType(t, child, ast.NodeContent)
+	}
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+)
+
+func assertPos(t *testing.T, node ast.Node, expected string) {
+	t.Helper()
+	if got := node.Pos().String(); got != expected {
+		t.Errorf("expected %v at %s, got %s", node.Type(), expected, got)
+	}
+}
+
+func TestPositionsBlocks(t *testing.T) {
+	input := "# Title\n\n> quote\n> more\n\n```go\ncode\n```\n"
+	doc := Parse(input)
+
+	assertPos(t, doc, "1:1-8:3")
+	assertPos(t, assertChild(t, doc, 0, ast.NodeHeading), "1:1-1:7")
+	quote := assertChild(t, doc, 1, ast.NodeBlockQuote)
+	assertPos(t, quote, "3:1-4:6")
+	assertPos(t, assertChild(t, quote, 0, ast.NodeParagraph), "3:3-4:6")
+	assertPos(t, assertChild(t, doc, 2, ast.NodeCodeBlock), "6:1-8:3")
+}
+
+func TestPositionsIndentedCodeLine(t *testing.T) {
+	doc := Parse("```\n    x\n```")
+	code := assertChild(t, doc, 0, ast.NodeCodeBlock)
+	text := assertChild(t, code, 0, ast.NodeContent)
+	assertContent(t, text, "    x")
+	assertPos(t, text, "2:1-2:5")
+}
+
+func TestPositionsOffsets(t *testing.T) {
+	doc := Parse("a\r\n\r\n  b")
+	p := assertChild(t, doc, 1, ast.NodeParagraph)
+	pos := p.Pos()
+	if pos.Start.Offset != 7 || pos.End.Offset != 8 {
+		t.Errorf("expected offsets 7-8, got %d-%d", pos.Start.Offset, pos.End.Offset)
+	}
+	assertPos(t, p, "3:3-3:3")
+}
+
+func TestPositionsLazyContinuation(t *testing.T) {
+	doc := Parse("> a\nb")
+	quote := assertChild(t, doc, 0, ast.NodeBlockQuote)
+	assertPos(t, quote, "1:1-2:1")
+	assertPos(t, assertChild(t, quote, 0, ast.NodeParagraph), "1:3-2:1")
+}
+
+func TestPositionsFencedDiv(t *testing.T) {
+	doc := Parse("::: note\ntext\n\n:::\nafter")
+	div := assertChild(t, doc, 0, ast.NodeFencedDiv)
+	assertPos(t, div, "1:1-4:3")
+	assertPos(t, assertChild(t, div, 0, ast.NodeParagraph), "2:1-2:4")
+}
+
+func TestPositionsInlines(t *testing.T) {
+	doc := Parse("a **b** `c`\n  *d* \\*")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertChildCount(t, p, 8)
+
+	assertPos(t, assertChild(t, p, 0, ast.NodeContent), "1:1-1:2")
+	strong := assertChild(t, p, 1, ast.NodeStrong)
+	assertPos(t, strong, "1:3-1:7")
+	assertPos(t, assertChild(t, strong, 0, ast.NodeContent), "1:5-1:5")
+	assertPos(t, assertChild(t, p, 3, ast.NodeCodeSpan), "1:9-1:11")
+	assertPos(t, assertChild(t, p, 4, ast.NodeSoftBreak), "1:12-1:12")
+	assertPos(t, assertChild(t, p, 5, ast.NodeEmphasis), "2:3-2:5")
+	assertPos(t, assertChild(t, p, 7, ast.NodeContent), "2:7-2:8")
+}
+
+func TestPositionsPartialDelimiterRun(t *testing.T) {
+	doc := Parse("***a* b**")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	strong := assertChild(t, p, 0, ast.NodeStrong)
+	assertPos(t, strong, "1:1-1:9")
+	assertPos(t, assertChild(t, strong, 0, ast.NodeEmphasis), "1:3-1:5")
+}
+
+func TestPositionsLink(t *testing.T) {
+	doc := Parse("see [x](/y) now")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertPos(t, assertChild(t, p, 1, ast.NodeLink), "1:5-1:11")
+}
+
+func TestPositionsAbbreviation(t *testing.T) {
+	doc := Parse("the HTML spec\n\n*[HTML]: Hyper Text Markup Language")
+	p := assertChild(t, doc, 0, ast.NodeParagraph)
+	assertPos(t, assertChild(t, p, 0, ast.NodeContent), "1:1-1:4")
+	assertPos(t, assertChild(t, p, 1, ast.NodeAbbreviation), "1:5-1:8")
+	assertPos(t, assertChild(t, p, 2, ast.NodeContent), "1:9-1:13")
+}
+
+func TestPositionsInlineTrigger(t *testing.T) {
+	doc := New(WithExtensions(mentions{})).Parse("> hi @bob")
+	quote := assertChild(t, doc, 0, ast.NodeBlockQuote)
+	p := assertChild(t, quote, 0, ast.NodeParagraph)
+	link := assertChild(t, p, 1, ast.NodeLink)
+	assertPos(t, link, "1:6-1:9")
+	assertPos(t, assertChild(t, link, 0, ast.NodeContent), "1:6-1:9")
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// ParseReader parses the Markdown read from r with the default extensions.
+func ParseReader(r io.Reader) (*ast.Document, error) {
+	return defaultParser.ParseReader(r)
+}
+
+// ParseReader is Parse for input read from r. Lines may be of any length,
+// and the first failed read is returned as the error rather than a document
+// of the input read before it.
+//
+// As in Parse, lines end at "\n", "\r\n" or "\r", a leading UTF-8 byte order
+// mark is skipped and NUL bytes are replaced by U+FFFD, as CommonMark
+// requires. Positions count bytes of the input after these changes.
+//
+// A lossless Parser leaves NUL bytes in the tree, where the HTML renderer
+// replaces them, and counts the byte order mark in positions, so positions
+// and snapshots match the input byte for byte.
+func (p *Parser) ParseReader(r io.Reader) (*ast.Document, error) {
+	return p.parse(r, nil)
+}
+
+const byteOrderMark = "\uFEFF"
+
+// lineReader splits input into Lines, numbering them and placing them in the
+// input as it goes.
+type lineReader struct {
+	r       *bufio.Reader
+	started bool
+	number  int
+	offset  int
+	// source holds the input read so far, byte for byte, if it is being
+	// kept. NUL bytes are then left in the lines too.
+	source *strings.Builder
+}
+
+// newLineReader returns a lineReader reading from r, keeping the input it
+// reads and its NUL bytes if keep is set.
+func newLineReader(r io.Reader, keep bool) *lineReader {
+	lines := &lineReader{r: bufio.NewReader(r)}
+	if keep {
+		lines.source = &strings.Builder{}
+	}
+	return lines
+}
+
+// next returns the next line, or nil at the end of the input.
+func (l *lineReader) next() (*Line, error) {
+	if !l.started {
+		l.started = true
+		if prefix, err := l.r.Peek(len(byteOrderMark)); err == nil && string(prefix) == byteOrderMark {
+			l.r.Discard(len(byteOrderMark))
+			if l.source != nil {
+				l.offset = len(byteOrderMark)
+				l.source.WriteString(byteOrderMark)
+			}
+		}
+	}
+
+	var text []byte
+	ending := ""
+	for ending == "" {
+		b, err := l.r.ReadByte()
+		if err == io.EOF {
+			if len(text) == 0 {
+				return nil, nil
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch b {
+		case '\n':
+			ending = "\n"
+		case '\r':
+			ending = "\r"
+			if next, err := l.r.Peek(1); err == nil && next[0] == '\n' {
+				l.r.Discard(1)
+				ending = "\r\n"
+			}
+		case 0:
+			if l.source == nil {
+				text = append(text, "\uFFFD"...)
+				break
+			}
+			fallthrough
+		default:
+			text = append(text, b)
+		}
+	}
+
+	line := NewLine(string(text))
+	l.number++
+	line.Number, line.Start = l.number, l.offset
+	l.offset += len(text) + len(ending)
+	if l.source != nil {
+		l.source.Write(text)
+		l.source.WriteString(ending)
+	}
+	return line, nil
+}
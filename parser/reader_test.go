@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rybkr/markee/ast"
+)
+
+func TestParseReaderLongLine(t *testing.T) {
+	long := strings.Repeat("x", 200_000)
+	doc, err := ParseReader(strings.NewReader("# Title\n\n" + long + "\n\nafter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertChildCount(t, doc, 3)
+	assertContent(t, assertChild(t, assertChild(t, doc, 1, ast.NodeParagraph), 0, ast.NodeContent), long)
+	assertContent(t, assertChild(t, assertChild(t, doc, 2, ast.NodeParagraph), 0, ast.NodeContent), "after")
+	assertPos(t, doc.LastChild(), "5:1-5:5")
+
+	// Parse shares the line reader, so it has no limit either.
+	if !ast.Equal(Parse("# Title\n\n"+long+"\n\nafter"), doc) {
+		t.Errorf("expected Parse to read the long line too")
+	}
+}
+
+func TestParseReaderLineEndings(t *testing.T) {
+	for _, input := range []string{"# A\n\nb\nc\n", "# A\r\n\r\nb\r\nc\r\n", "# A\r\rb\rc\r", "# A\n\r\nb\rc"} {
+		doc, err := ParseReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertChildCount(t, doc, 2)
+		p := assertChild(t, doc, 1, ast.NodeParagraph)
+		assertPos(t, p, "3:1-4:1")
+		assertContent(t, assertChild(t, p, 0, ast.NodeContent), "b")
+		assertChild(t, p, 1, ast.NodeSoftBreak)
+		assertContent(t, assertChild(t, p, 2, ast.NodeContent), "c")
+	}
+}
+
+func TestParseReaderNormalizesInput(t *testing.T) {
+	doc, err := ParseReader(strings.NewReader("\uFEFF# Title\n\na\x00b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	heading := assertChild(t, doc, 0, ast.NodeHeading)
+	assertPos(t, heading, "1:1-1:7")
+	assertContent(t, assertChild(t, heading, 0, ast.NodeContent), "Title")
+	assertContent(t, assertChild(t, assertChild(t, doc, 1, ast.NodeParagraph), 0, ast.NodeContent), "a\uFFFDb")
+
+	// A byte order mark elsewhere is text.
+	doc = Parse("a\n\uFEFFb")
+	assertContent(t, assertChild(t, assertChild(t, doc, 0, ast.NodeParagraph), 2, ast.NodeContent), "\uFEFFb")
+}
+
+func TestParseReaderError(t *testing.T) {
+	failing := errors.New("disk on fire")
+	r := io.MultiReader(strings.NewReader("# Title\n\ntext"), iotest.ErrReader(failing))
+	doc, err := ParseReader(r)
+	if !errors.Is(err, failing) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+	if doc != nil {
+		t.Errorf("expected no document")
+	}
+}
+
+func TestParseReaderLossless(t *testing.T) {
+	for _, input := range []string{"a\x00b\r\n\r\nc", "\uFEFF# x\n", "a\x00b\n"} {
+		doc, err := New(WithLossless()).ParseReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot, ok := ast.SnapshotOf(doc)
+		if !ok || snapshot.Source != input {
+			t.Errorf("expected the snapshot to hold the input as read, got %+v", snapshot)
+		}
+	}
+
+	// Positions count the byte order mark, and NUL bytes stay in the text.
+	doc := New(WithLossless()).Parse("\uFEFF# x\n\na\x00b")
+	heading := assertChild(t, doc, 0, ast.NodeHeading)
+	if start := heading.Pos().Start.Offset; start != 3 {
+		t.Errorf("expected the heading at offset 3, got %d", start)
+	}
+	text := assertChild(t, assertChild(t, doc, 1, ast.NodeParagraph), 0, ast.NodeContent)
+	assertContent(t, text, "a\x00b")
+	if pos := text.Pos(); pos.Start.Offset != 8 || pos.End.Offset != 11 {
+		t.Errorf("expected the text at 8-11, got %d-%d", pos.Start.Offset, pos.End.Offset)
+	}
+}
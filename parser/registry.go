@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// Extension adds syntax to a Parser by registering block matchers, inline
+// triggers and transforms. Name identifies the extension so it can be
+// switched off again with WithoutExtensions.
+type Extension interface {
+	Name() string
+	Extend(r *Registry)
+}
+
+// InlineFunc parses custom inline syntax at the current position of p, which
+// holds the byte it was registered for. It returns false, without consuming
+// any input, when the syntax does not match there.
+type InlineFunc func(p *InlineParser) bool
+
+// InlineSyntax parses custom inline syntax, such as @mentions or #123 issue
+// references, that starts with one of a few trigger bytes.
+type InlineSyntax interface {
+	// Triggers returns the bytes the syntax can start with.
+	Triggers() []byte
+	// Parse is called with the parser positioned at a trigger byte. It adds
+	// nodes to p.Container() and advances past the syntax, or returns false
+	// when the syntax does not match there.
+	Parse(p *InlineParser) bool
+}
+
+// Transform is a pass over the finished document, run after inline parsing in
+// ascending Priority order.
+type Transform struct {
+	Priority int
+	Name     string
+	Apply    func(doc *ast.Document)
+}
+
+// Registry collects the syntax a Parser recognizes. Extensions receive one
+// while the Parser is being built; it is not used after New returns.
+type Registry struct {
+	blockMatchers  []BlockMatcher
+	inlineTriggers map[byte][]InlineFunc
+	transforms     []Transform
+}
+
+func newRegistry() *Registry {
+	r := &Registry{inlineTriggers: make(map[byte][]InlineFunc)}
+	for _, m := range coreBlockMatchers {
+		r.AddBlockMatcher(m)
+	}
+	return r
+}
+
+// AddBlockMatcher registers m. A nil CanInterrupt is treated as
+// AlwaysInterrupt.
+func (r *Registry) AddBlockMatcher(m BlockMatcher) {
+	if m.CanInterrupt == nil {
+		m.CanInterrupt = AlwaysInterrupt
+	}
+	r.blockMatchers = append(r.blockMatchers, m)
+}
+
+// AddInlineTrigger registers fn to run whenever the inline parser reaches c.
+// Functions for the same byte are tried in registration order, all before
+// the built-in syntax for that byte.
+func (r *Registry) AddInlineTrigger(c byte, fn InlineFunc) {
+	r.inlineTriggers[c] = append(r.inlineTriggers[c], fn)
+}
+
+// AddInlineSyntax registers s for each of its trigger bytes.
+func (r *Registry) AddInlineSyntax(s InlineSyntax) {
+	for _, c := range s.Triggers() {
+		r.AddInlineTrigger(c, s.Parse)
+	}
+}
+
+// AddTransform registers a post-processing pass.
+func (r *Registry) AddTransform(t Transform) {
+	r.transforms = append(r.transforms, t)
+}
+
+// sort orders matchers and transforms by priority, keeping registration
+// order among equal priorities.
+func (r *Registry) sort() {
+	sort.SliceStable(r.blockMatchers, func(i, j int) bool {
+		return r.blockMatchers[i].Priority < r.blockMatchers[j].Priority
+	})
+	sort.SliceStable(r.transforms, func(i, j int) bool {
+		return r.transforms[i].Priority < r.transforms[j].Priority
+	})
+}
@@ -0,0 +1,181 @@
+package renderer
+
+import (
+	"fmt"
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/highlight"
+	"strconv"
+	"strings"
+)
+
+// renderCodeBlock renders a code block with syntax highlighting and, when
+// enabled, its attributes applied:
+//
+//	title="main.go"   wraps the block in a <figure> with a <figcaption>
+//	linenos=true      prefixes each line with its number
+//	start=10          numbers lines from 10 instead of 1
+//	hl_lines="2-4 7"  marks lines 2 to 4 and 7 of the block as highlighted
+//
+// With attributes, each line is wrapped in <span class="line">, with an extra
+// "hl" class on highlighted lines. hl_lines counts from the first line of the
+// block, regardless of start.
+func (r *HTMLRenderer) renderCodeBlock(codeBlock *ast.CodeBlock) {
+	annotate := r.codeAttributes && hasCodeAnnotations(codeBlock)
+	attrs := codeBlock.Attributes
+
+	title, hasTitle := ast.LookupAttribute(attrs, "title")
+	hasTitle = hasTitle && annotate
+	linenos := false
+	if value, ok := ast.LookupAttribute(attrs, "linenos"); ok {
+		linenos = value == "" || value == "true"
+	}
+	start := 1
+	if value, ok := ast.LookupAttribute(attrs, "start"); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			start = n
+		}
+	}
+	highlighted := make(map[int]bool)
+	if value, ok := ast.LookupAttribute(attrs, "hl_lines"); ok {
+		highlighted = parseLineRanges(value, len(codeLines(codeBlock)))
+	}
+
+	if hasTitle {
+		r.output.WriteString("<figure")
+		r.WriteAttributes(codeBlock, ast.Attribute{Key: "class", Value: "code-block"})
+		r.output.WriteString(">\n")
+		r.output.WriteString(fmt.Sprintf("<figcaption>%s</figcaption>\n", escapeHTML(title)))
+		r.output.WriteString("<pre><code")
+	} else {
+		r.output.WriteString("<pre")
+		r.WriteAttributes(codeBlock)
+		r.output.WriteString("><code")
+	}
+	if codeBlock.Language != "" {
+		r.output.WriteString(fmt.Sprintf(" class=\"language-%s\"", escapeAttribute(codeBlock.Language)))
+	}
+	r.output.WriteString(">")
+
+	lines := r.codeTokenLines(codeBlock)
+	for i, line := range lines {
+		if annotate {
+			if highlighted[i+1] {
+				r.output.WriteString("<span class=\"line hl\">")
+			} else {
+				r.output.WriteString("<span class=\"line\">")
+			}
+			if linenos {
+				r.output.WriteString(fmt.Sprintf("<span class=\"line-number\">%d</span>", start+i))
+			}
+		}
+		r.writeTokens(line)
+		if annotate {
+			r.output.WriteString("</span>")
+		}
+		if i < len(lines)-1 {
+			r.output.WriteString("\n")
+		}
+	}
+
+	r.output.WriteString("</code></pre>\n")
+	if hasTitle {
+		r.output.WriteString("</figure>\n")
+	}
+}
+
+// highlights reports whether codeBlock will be syntax highlighted.
+func (r *HTMLRenderer) highlights(codeBlock *ast.CodeBlock) bool {
+	if !r.highlighting {
+		return false
+	}
+	_, ok := highlight.Lookup(codeBlock.Language)
+	return ok
+}
+
+// codeTokenLines returns the code block's lines as tokens. Without
+// highlighting each line is a single Text token.
+func (r *HTMLRenderer) codeTokenLines(codeBlock *ast.CodeBlock) [][]highlight.Token {
+	lines := codeLines(codeBlock)
+	if r.highlights(codeBlock) {
+		source := strings.Join(lines, "\n")
+		return highlight.SplitLines(highlight.Tokenize(codeBlock.Language, source))
+	}
+
+	tokenLines := make([][]highlight.Token, len(lines))
+	for i, line := range lines {
+		tokenLines[i] = []highlight.Token{{Type: highlight.Text, Value: line}}
+	}
+	return tokenLines
+}
+
+// writeTokens writes tokens as escaped text, wrapping every token other than
+// plain text in a span carrying its class or its theme style.
+func (r *HTMLRenderer) writeTokens(tokens []highlight.Token) {
+	for _, token := range tokens {
+		value := escapeHTML(token.Value)
+		if token.Type == highlight.Text {
+			r.output.WriteString(value)
+			continue
+		}
+
+		if r.theme != nil {
+			style, ok := r.theme[token.Type]
+			if !ok {
+				r.output.WriteString(value)
+				continue
+			}
+			r.output.WriteString(fmt.Sprintf("<span style=\"%s\">%s</span>", escapeAttribute(style), value))
+		} else {
+			r.output.WriteString(fmt.Sprintf("<span class=\"tok-%s\">%s</span>", token.Type, value))
+		}
+	}
+}
+
+// hasCodeAnnotations reports whether a code block sets any attribute that
+// renderCodeBlock acts on.
+func hasCodeAnnotations(codeBlock *ast.CodeBlock) bool {
+	for _, key := range []string{"title", "linenos", "hl_lines"} {
+		if _, ok := ast.LookupAttribute(codeBlock.Attributes, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLines returns the lines of a code block's content.
+func codeLines(codeBlock *ast.CodeBlock) []string {
+	lines := make([]string, 0)
+	for child := codeBlock.FirstChild(); child != nil; child = child.NextSibling() {
+		if content, ok := child.(*ast.Content); ok {
+			lines = append(lines, content.Literal)
+		}
+	}
+	return lines
+}
+
+// parseLineRanges parses a list of line numbers and ranges separated by
+// spaces or commas, such as "1 3-5,8", keeping the numbers from 1 to count.
+// Malformed entries are skipped.
+func parseLineRanges(s string, count int) map[int]bool {
+	lines := make(map[int]bool)
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for _, field := range fields {
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil {
+				continue
+			}
+		}
+		for n := max(first, 1); n <= min(last, count); n++ {
+			lines[n] = true
+		}
+	}
+	return lines
+}
@@ -0,0 +1,32 @@
+package renderer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+func TestCodeBlockHugeLineRange(t *testing.T) {
+	doc := parser.Parse("```go {hl_lines=\"1-30000000\"}\nx := 1\n```\n")
+	start := time.Now()
+	html := renderer.RenderHTML(doc, renderer.WithCodeBlockAttributes())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the range to be clamped to the block, took %v", elapsed)
+	}
+	if !strings.Contains(html, `<span class="line hl">x := 1</span>`) {
+		t.Errorf("expected the only line to be highlighted, got %q", html)
+	}
+}
+
+func TestCodeBlockLanguageEscaped(t *testing.T) {
+	html := renderer.RenderHTML(parser.Parse("```a\"onclick=\"alert(1)\nx\n```\n"))
+	if strings.Contains(html, `"onclick="`) {
+		t.Errorf("expected the language to be escaped, got %q", html)
+	}
+	if !strings.Contains(html, `class="language-a&quot;onclick=&quot;alert(1)"`) {
+		t.Errorf("expected the escaped language class, got %q", html)
+	}
+}
@@ -0,0 +1,68 @@
+package renderer_test
+
+import (
+	"fmt"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+var nodeKeyboard = ast.RegisterNodeType("kbd", ast.CategoryInline)
+
+// Keyboard is an extension node for a key press.
+type Keyboard struct {
+	ast.BaseNode
+	Key string
+}
+
+func (k *Keyboard) Accept(v ast.Visitor) {
+	v.VisitNode(k)
+}
+
+func ExampleWithNodeRenderer() {
+	paragraph := ast.NewParagraph()
+	paragraph.AddChild(ast.NewContent("Press "))
+	paragraph.AddChild(&Keyboard{BaseNode: ast.New(nodeKeyboard), Key: "Ctrl+C"})
+	doc := ast.NewDocument()
+	doc.AddChild(paragraph)
+
+	html := renderer.RenderHTML(doc, renderer.WithNodeRenderer(nodeKeyboard, func(r *renderer.HTMLRenderer, node ast.Node) {
+		r.WriteString("<kbd>" + node.(*Keyboard).Key + "</kbd>")
+	}))
+	fmt.Print(html)
+	// Output:
+	// <p>Press <kbd>Ctrl+C</kbd></p>
+}
+
+func ExampleWithSourcePositions() {
+	doc := parser.Parse("# Title\n\n> Some `code`\n> here.")
+	fmt.Print(renderer.RenderHTML(doc, renderer.WithSourcePositions()))
+	// Output:
+	// <h1 data-sourcepos="1:1-1:7">Title</h1>
+	// <blockquote data-sourcepos="3:1-4:7">
+	// <p data-sourcepos="3:3-4:7">Some <code>code</code>
+	// here.</p>
+	// </blockquote>
+}
+
+func ExampleWithInlineSourcePositions() {
+	doc := parser.Parse("Some `code`.")
+	fmt.Print(renderer.RenderHTML(doc, renderer.WithInlineSourcePositions()))
+	// Output:
+	// <p data-sourcepos="1:1-1:12">Some <code data-sourcepos="1:6-1:11">code</code>.</p>
+}
+
+func ExampleHTMLRenderer_WriteAttributes() {
+	doc := parser.Parse("::: note\nSee the guide.\n:::")
+	div := doc.FirstChild()
+	div.SetAttr("class", "boxed")
+	div.SetAttr("role", "note")
+	div.FirstChild().SetAttr("id", "see-guide")
+
+	fmt.Print(renderer.RenderHTML(doc))
+	// Output:
+	// <div class="note boxed" role="note">
+	// <p id="see-guide">See the guide.</p>
+	// </div>
+}
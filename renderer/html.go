@@ -0,0 +1,485 @@
+package renderer
+
+import (
+    "cmp"
+    "fmt"
+    "github.com/rybkr/markee/ast"
+    "github.com/rybkr/markee/highlight"
+    "github.com/rybkr/markee/toc"
+    "strings"
+)
+
+type HTMLRenderer struct {
+    ast.BaseVisitor
+    output     strings.Builder
+    headingIDs bool
+    tocOptions toc.Options
+    outline    []*toc.Entry
+    ids        map[*ast.Heading]string
+    divs       map[string]DivRenderer
+    nodes      map[ast.NodeType]NodeRenderer
+
+    codeAttributes bool
+    highlighting   bool
+    theme          highlight.Theme
+
+    sourcePos       bool
+    inlineSourcePos bool
+    eventHandlers   bool
+
+    redlineBase *ast.Document
+    redline     *redline
+}
+
+// DivRenderer renders a fenced div in place of the default <div> markup. It
+// can use WriteString and RenderChildren to produce its output.
+type DivRenderer func(r *HTMLRenderer, div *ast.FencedDiv)
+
+// NodeRenderer renders a node of a type registered with ast.RegisterNodeType.
+type NodeRenderer func(r *HTMLRenderer, node ast.Node)
+
+// HTMLOption configures optional behaviour of an HTMLRenderer.
+type HTMLOption func(*HTMLRenderer)
+
+// WithHeadingIDs gives every heading a generated id attribute.
+func WithHeadingIDs() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.headingIDs = true
+    }
+}
+
+// WithTOCLevels limits the headings listed by [TOC] placeholders.
+func WithTOCLevels(minLevel, maxLevel int) HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.tocOptions = toc.Options{MinLevel: minLevel, MaxLevel: maxLevel}
+    }
+}
+
+// WithDivRenderer renders fenced divs called name with fn.
+func WithDivRenderer(name string, fn DivRenderer) HTMLOption {
+    return func(r *HTMLRenderer) {
+        if r.divs == nil {
+            r.divs = make(map[string]DivRenderer)
+        }
+        r.divs[name] = fn
+    }
+}
+
+// WithNodeRenderer renders nodes of the registered type t with fn. Nodes of a
+// registered type without a renderer only have their children rendered.
+func WithNodeRenderer(t ast.NodeType, fn NodeRenderer) HTMLOption {
+    return func(r *HTMLRenderer) {
+        if r.nodes == nil {
+            r.nodes = make(map[ast.NodeType]NodeRenderer)
+        }
+        r.nodes[t] = fn
+    }
+}
+
+// WithSourcePositions adds a data-sourcepos="3:1-5:12" attribute, as cmark
+// does with --sourcepos, to the element of every block that has a position.
+func WithSourcePositions() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.sourcePos = true
+    }
+}
+
+// WithInlineSourcePositions adds data-sourcepos to inline elements such as
+// links and code spans as well as to blocks.
+func WithInlineSourcePositions() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.sourcePos = true
+        r.inlineSourcePos = true
+    }
+}
+
+// WithEventHandlers keeps event handler attributes such as onclick, which
+// are dropped by default. Only use it for trusted input.
+func WithEventHandlers() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.eventHandlers = true
+    }
+}
+
+// WithCodeBlockAttributes honours the linenos, start, hl_lines and title
+// attributes of fenced code blocks.
+func WithCodeBlockAttributes() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.codeAttributes = true
+    }
+}
+
+// WithSyntaxHighlighting highlights code blocks in known languages, marking
+// tokens with tok-* classes such as tok-keyword.
+func WithSyntaxHighlighting() HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.highlighting = true
+    }
+}
+
+// WithHighlightTheme highlights code blocks in known languages using inline
+// styles from theme instead of classes.
+func WithHighlightTheme(theme highlight.Theme) HTMLOption {
+    return func(r *HTMLRenderer) {
+        r.highlighting = true
+        r.theme = theme
+    }
+}
+
+func NewHTMLRenderer(opts ...HTMLOption) *HTMLRenderer {
+    r := &HTMLRenderer{
+        tocOptions: toc.DefaultOptions(),
+    }
+    for _, opt := range opts {
+        opt(r)
+    }
+    return r
+}
+
+func RenderHTML(doc *ast.Document, opts ...HTMLOption) string {
+    return NewHTMLRenderer(opts...).Render(doc)
+}
+
+// Render returns the HTML for doc. Heading IDs generated for WithHeadingIDs
+// or for the links of a table of contents are only written to the output;
+// doc is not changed.
+func (r *HTMLRenderer) Render(doc *ast.Document) string {
+    r.output.Reset()
+    r.ids, r.outline = nil, nil
+    hasTOC := containsTOC(doc)
+    if r.headingIDs || hasTOC {
+        r.ids = toc.IDs(doc)
+    }
+    if hasTOC {
+        r.outline = toc.Build(doc, r.tocOptions)
+    }
+    r.redline = nil
+    if r.redlineBase != nil {
+        r.redline = newRedline(ast.Diff(r.redlineBase, doc))
+    }
+    doc.Accept(r)
+    return r.output.String()
+}
+
+// WriteString writes raw HTML to the output.
+func (r *HTMLRenderer) WriteString(s string) {
+    r.output.WriteString(s)
+}
+
+// WriteSourcePos writes the data-sourcepos attribute of node if source
+// positions are enabled for its kind of node.
+func (r *HTMLRenderer) WriteSourcePos(node ast.Node) {
+    pos := node.Pos()
+    if !pos.IsValid() || !r.sourcePos || (node.Type().IsInline() && !r.inlineSourcePos) {
+        return
+    }
+    r.output.WriteString(fmt.Sprintf(" data-sourcepos=\"%s\"", pos))
+}
+
+// WriteAttributes writes attrs, then the generic attributes of node, then its
+// source position as HTML attributes. All classes are merged into a single
+// class attribute written first; a generic attribute replaces one of the
+// same name in attrs. Keys that are not valid attribute names are skipped,
+// and so are event handlers such as onclick unless WithEventHandlers is set.
+func (r *HTMLRenderer) WriteAttributes(node ast.Node, attrs ...ast.Attribute) {
+    for _, attr := range mergeAttributes(attrs, node.Attrs()) {
+        if !ast.IsValidAttributeKey(attr.Key) || (ast.IsEventHandlerKey(attr.Key) && !r.eventHandlers) {
+            continue
+        }
+        r.output.WriteString(fmt.Sprintf(" %s=\"%s\"", attr.Key, escapeAttribute(attr.Value)))
+    }
+    r.WriteSourcePos(node)
+}
+
+func mergeAttributes(base, extra []ast.Attribute) []ast.Attribute {
+    var classes []string
+    var merged []ast.Attribute
+    add := func(attr ast.Attribute) {
+        if attr.Key == "class" {
+            classes = append(classes, attr.Value)
+            return
+        }
+        for i := range merged {
+            if merged[i].Key == attr.Key {
+                merged[i].Value = attr.Value
+                return
+            }
+        }
+        merged = append(merged, attr)
+    }
+    for _, attr := range base {
+        add(attr)
+    }
+    for _, attr := range extra {
+        add(attr)
+    }
+
+    if len(classes) > 0 {
+        class := ast.Attribute{Key: "class", Value: strings.Join(classes, " ")}
+        merged = append([]ast.Attribute{class}, merged...)
+    }
+    return merged
+}
+
+// RenderChildren renders the children of node in order.
+func (r *HTMLRenderer) RenderChildren(node ast.Node) {
+    r.walkChildren(node)
+}
+
+func (r *HTMLRenderer) VisitDocument(node ast.Node) {
+    r.walkChildren(node)
+}
+
+func (r *HTMLRenderer) VisitFencedDiv(node ast.Node) {
+    div := node.(*ast.FencedDiv)
+    if fn, ok := r.divs[div.Name]; ok && div.Name != "" {
+        fn(r, div)
+        return
+    }
+
+    var attrs []ast.Attribute
+    if div.Name != "" {
+        attrs = append(attrs, ast.Attribute{Key: "class", Value: div.Name})
+    }
+    r.output.WriteString("<div")
+    r.WriteAttributes(node, append(attrs, div.Attributes...)...)
+    r.output.WriteString(">\n")
+    r.walkChildren(node)
+    r.output.WriteString("</div>\n")
+}
+
+func (r *HTMLRenderer) VisitBlockQuote(node ast.Node) {
+    r.output.WriteString("<blockquote")
+    r.WriteAttributes(node)
+    r.output.WriteString(">\n")
+    r.walkChildren(node)
+    r.output.WriteString("</blockquote>\n")
+}
+
+func (r *HTMLRenderer) VisitCodeBlock(node ast.Node) {
+    codeBlock := node.(*ast.CodeBlock)
+    if r.highlights(codeBlock) || (r.codeAttributes && hasCodeAnnotations(codeBlock)) {
+        r.renderCodeBlock(codeBlock)
+        return
+    }
+    
+    r.output.WriteString("<pre")
+    r.WriteAttributes(node)
+    r.output.WriteString("><code")
+    if codeBlock.Language != "" {
+        r.output.WriteString(fmt.Sprintf(" class=\"language-%s\"", escapeAttribute(codeBlock.Language)))
+    }
+    r.output.WriteString(">")
+    
+    for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+        if content, ok := child.(*ast.Content); ok {
+            r.output.WriteString(escapeHTML(content.Literal))
+            if child.NextSibling() != nil {
+                r.output.WriteString("\n")
+            }
+        }
+    }
+    
+    r.output.WriteString("</code></pre>\n")
+}
+
+func (r *HTMLRenderer) VisitHeading(node ast.Node) {
+    heading := node.(*ast.Heading)
+    var attrs []ast.Attribute
+    if id := cmp.Or(heading.ID, r.ids[heading]); id != "" {
+        attrs = append(attrs, ast.Attribute{Key: "id", Value: id})
+    }
+    r.output.WriteString(fmt.Sprintf("<h%d", heading.Level))
+    r.WriteAttributes(node, attrs...)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString(fmt.Sprintf("</h%d>\n", heading.Level))
+}
+
+func (r *HTMLRenderer) VisitParagraph(node ast.Node) {
+    r.output.WriteString("<p")
+    r.WriteAttributes(node)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</p>\n")
+}
+
+func (r *HTMLRenderer) VisitThematicBreak(node ast.Node) {
+    r.output.WriteString("<hr")
+    r.WriteAttributes(node)
+    r.output.WriteString(" />\n")
+}
+
+func (r *HTMLRenderer) VisitTOC(node ast.Node) {
+    r.writeOutline(r.outline, node)
+}
+
+// writeOutline writes entries as nested lists. The outermost list carries
+// the source position of the placeholder node; nested lists pass nil.
+func (r *HTMLRenderer) writeOutline(entries []*toc.Entry, placeholder ast.Node) {
+    if len(entries) == 0 {
+        return
+    }
+    r.output.WriteString("<ul")
+    if placeholder != nil {
+        r.WriteAttributes(placeholder)
+    }
+    r.output.WriteString(">\n")
+    for _, entry := range entries {
+        r.output.WriteString(fmt.Sprintf("<li><a href=\"#%s\">%s</a>",
+            escapeAttribute(entry.ID), escapeHTML(entry.Text)))
+        if len(entry.Children) > 0 {
+            r.output.WriteString("\n")
+            r.writeOutline(entry.Children, nil)
+        }
+        r.output.WriteString("</li>\n")
+    }
+    r.output.WriteString("</ul>\n")
+}
+
+func containsTOC(node ast.Node) bool {
+    if node.Type() == ast.NodeTOC {
+        return true
+    }
+    for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+        if child.Type().IsBlock() && containsTOC(child) {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *HTMLRenderer) VisitList(node ast.Node) {
+    list := node.(*ast.List)
+    tag := "ul"
+    if list.IsOrdered {
+        tag = "ol"
+    }
+    
+    r.output.WriteString("<" + tag)
+    r.WriteAttributes(node)
+    r.output.WriteString(">\n")
+    r.walkChildren(node)
+    r.output.WriteString(fmt.Sprintf("</%s>\n", tag))
+}
+
+func (r *HTMLRenderer) VisitListItem(node ast.Node) {
+    r.output.WriteString("<li")
+    r.WriteAttributes(node)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</li>\n")
+}
+
+func (r *HTMLRenderer) VisitStrong(node ast.Node) {
+    r.output.WriteString("<strong")
+    r.WriteAttributes(node)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</strong>")
+}
+
+func (r *HTMLRenderer) VisitEmphasis(node ast.Node) {
+    r.output.WriteString("<em")
+    r.WriteAttributes(node)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</em>")
+}
+
+func (r *HTMLRenderer) VisitStrikethrough(node ast.Node) {
+    r.output.WriteString("<del")
+    r.WriteAttributes(node)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</del>")
+}
+
+func (r *HTMLRenderer) VisitCodeSpan(node ast.Node) {
+    if code, ok := node.(*ast.CodeSpan); ok {
+        r.output.WriteString("<code")
+        r.WriteAttributes(node)
+        r.output.WriteString(">")
+        r.output.WriteString(escapeHTML(code.Literal))
+        r.output.WriteString("</code>")
+    }
+}
+
+func (r *HTMLRenderer) VisitLink(node ast.Node) {
+    if link, ok := node.(*ast.Link); ok {
+        attrs := []ast.Attribute{{Key: "href", Value: link.Destination}}
+        if link.Title != "" {
+            attrs = append(attrs, ast.Attribute{Key: "title", Value: link.Title})
+        }
+        r.output.WriteString("<a")
+        r.WriteAttributes(node, attrs...)
+        r.output.WriteString(">")
+        r.walkChildren(node)
+        r.output.WriteString("</a>")
+    }
+}
+
+func (r *HTMLRenderer) VisitImage(node ast.Node) {
+    if image, ok := node.(*ast.Image); ok {
+        attrs := []ast.Attribute{{Key: "src", Value: image.Destination}, {Key: "alt", Value: image.AltText}}
+        if image.Title != "" {
+            attrs = append(attrs, ast.Attribute{Key: "title", Value: image.Title})
+        }
+        r.output.WriteString("<img")
+        r.WriteAttributes(node, attrs...)
+        r.output.WriteString(" />")
+    }
+}
+
+func (r *HTMLRenderer) VisitAbbreviation(node ast.Node) {
+    abbr := node.(*ast.Abbreviation)
+    var attrs []ast.Attribute
+    if abbr.Title != "" {
+        attrs = append(attrs, ast.Attribute{Key: "title", Value: abbr.Title})
+    }
+    r.output.WriteString("<abbr")
+    r.WriteAttributes(node, attrs...)
+    r.output.WriteString(">")
+    r.walkChildren(node)
+    r.output.WriteString("</abbr>")
+}
+
+func (r *HTMLRenderer) VisitNode(node ast.Node) {
+    if fn, ok := r.nodes[node.Type()]; ok {
+        fn(r, node)
+        return
+    }
+    r.walkChildren(node)
+}
+
+func (r *HTMLRenderer) VisitSoftBreak(node ast.Node) {
+    r.output.WriteString("\n")
+}
+
+func (r *HTMLRenderer) VisitLineBreak(node ast.Node) {
+    r.output.WriteString("<br />\n")
+}
+
+func (r *HTMLRenderer) VisitContent(node ast.Node) {
+    if content, ok := node.(*ast.Content); ok {
+        r.output.WriteString(escapeHTML(content.Literal))
+    }
+}
+
+// escapeHTML escapes s for use as text. It also replaces the NUL bytes a
+// lossless parse keeps, as CommonMark requires.
+func escapeHTML(s string) string {
+    s = strings.ReplaceAll(s, "\x00", "\uFFFD")
+    s = strings.ReplaceAll(s, "&", "&amp;")
+    s = strings.ReplaceAll(s, "<", "&lt;")
+    s = strings.ReplaceAll(s, ">", "&gt;")
+    s = strings.ReplaceAll(s, "\"", "&quot;")
+    return s
+}
+
+func escapeAttribute(s string) string {
+    s = strings.ReplaceAll(s, "\x00", "\uFFFD")
+    s = strings.ReplaceAll(s, "&", "&amp;")
+    s = strings.ReplaceAll(s, "\"", "&quot;")
+    return s
+}
@@ -0,0 +1,92 @@
+package renderer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+func TestHostileAttributeKeysSkipped(t *testing.T) {
+	html := renderer.RenderHTML(parser.Parse("::: note {onmouseover=alert(1) x><script>alert(1)</script}\nfoo\n:::\n"))
+	if strings.Contains(html, "<script>") || strings.Contains(html, `onmouseover="`) {
+		t.Errorf("expected the hostile attributes to be rejected, got %q", html)
+	}
+
+	doc := parser.Parse("foo\n")
+	p := doc.FirstChild()
+	p.SetAttr(`x><script>alert(1)</script`, "")
+	p.SetAttr("onclick", "alert(1)")
+	p.SetAttr("data-ok", "1")
+	html = renderer.RenderHTML(doc)
+	if html != "<p data-ok=\"1\">foo</p>\n" {
+		t.Errorf("expected only the valid attribute, got %q", html)
+	}
+
+	html = renderer.RenderHTML(doc, renderer.WithEventHandlers())
+	if !strings.Contains(html, `onclick="alert(1)"`) || strings.Contains(html, "<script>") {
+		t.Errorf("expected the event handler to be kept, got %q", html)
+	}
+}
+
+func TestValidAttributeKeys(t *testing.T) {
+	for key, valid := range map[string]bool{
+		"id": true, "data-x": true, "_a.b:c": true, ":x": true,
+		"": false, "1a": false, "-a": false, "a b": false, "a>": false, `a"`: false,
+	} {
+		if got := ast.IsValidAttributeKey(key); got != valid {
+			t.Errorf("IsValidAttributeKey(%q) = %v", key, got)
+		}
+	}
+}
+
+func TestEmphasisAndLineBreak(t *testing.T) {
+	html := renderer.RenderHTML(parser.Parse("*a* b\\\nc\n"))
+	if html != "<p><em>a</em> b<br />\nc</p>\n" {
+		t.Errorf("unexpected output %q", html)
+	}
+
+	html = renderer.RenderHTML(parser.Parse("x *a*\n"), renderer.WithInlineSourcePositions())
+	if !strings.Contains(html, `<em data-sourcepos="1:3-1:5">a</em>`) {
+		t.Errorf("expected emphasis to have a source position, got %q", html)
+	}
+}
+
+func TestStrikethrough(t *testing.T) {
+	doc := parser.New(parser.WithGFM()).Parse("~~old~~ see www.example.com\n")
+	html := renderer.RenderHTML(doc)
+	if html != "<p><del>old</del> see <a href=\"http://www.example.com\">www.example.com</a></p>\n" {
+		t.Errorf("unexpected output %q", html)
+	}
+	if md := renderer.RenderMarkdown(doc); md != "~~old~~ see [www.example.com](http://www.example.com)\n" {
+		t.Errorf("unexpected Markdown %q", md)
+	}
+}
+
+func TestTOCPlaceholders(t *testing.T) {
+	expected := "<ul>\n<li><a href=\"#intro\">Intro</a>\n<ul>\n<li><a href=\"#install\">Install</a></li>\n</ul>\n</li>\n</ul>\n" +
+		"<h1 id=\"intro\">Intro</h1>\n<h2 id=\"install\">Install</h2>\n"
+	for _, placeholder := range []string{"[TOC]", "<!-- toc -->"} {
+		doc := parser.Parse(placeholder + "\n\n# Intro\n\n## Install\n")
+		if html := renderer.RenderHTML(doc); html != expected {
+			t.Errorf("%s: expected %q, got %q", placeholder, expected, html)
+		}
+		for heading := range ast.OfType[*ast.Heading](ast.All(doc)) {
+			if heading.ID != "" {
+				t.Errorf("%s: expected rendering to leave heading ids unset, got %q", placeholder, heading.ID)
+			}
+		}
+	}
+}
+
+func TestHeadingIDsLeaveDocumentUnchanged(t *testing.T) {
+	doc := parser.Parse("# Intro\n")
+	if html := renderer.RenderHTML(doc, renderer.WithHeadingIDs()); html != "<h1 id=\"intro\">Intro</h1>\n" {
+		t.Errorf("unexpected output %q", html)
+	}
+	if id := doc.FirstChild().(*ast.Heading).ID; id != "" {
+		t.Errorf("expected the heading id to stay unset, got %q", id)
+	}
+}
@@ -0,0 +1,234 @@
+package renderer
+
+import (
+	"strings"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// WithLossless prints documents parsed with parser.WithLossless from their
+// source. Nodes that still match their ast.Snapshot are copied byte for
+// byte, containers whose children changed keep the text between the children
+// that remain, and only nodes whose fields changed, or that were added, are
+// written in the renderer's style. Other options then apply to those nodes
+// alone. Documents without a snapshot are rendered as usual.
+func WithLossless() MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		r.lossless = true
+	}
+}
+
+// renderLossless prints doc from source. It is Render in lossless mode.
+func (r *MarkdownRenderer) renderLossless(doc *ast.Document, source string) string {
+	r.source = source
+	defer func() { r.source = "" }()
+
+	raw, _ := r.printRaw(doc)
+	return raw
+}
+
+// original returns the snapshot of node if it was taken against the source
+// being printed.
+func (r *MarkdownRenderer) original(node ast.Node) (*ast.Snapshot, bool) {
+	snapshot, ok := ast.SnapshotOf(node)
+	if !ok || snapshot.Source != r.source || !snapshot.Pos.IsValid() {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+// span returns the byte range of node in the source. The document covers
+// the whole source, including any text after its last line.
+func (r *MarkdownRenderer) span(node ast.Node, snapshot *ast.Snapshot) (int, int) {
+	if _, ok := node.(*ast.Document); ok {
+		return 0, len(r.source)
+	}
+	return snapshot.Pos.Start.Offset, snapshot.Pos.End.Offset
+}
+
+// printRaw prints node as it would appear at its place in the source, with
+// the markers of the containers around it at the start of each line after
+// the first. It reports false for nodes that do not come from the source.
+func (r *MarkdownRenderer) printRaw(node ast.Node) (string, bool) {
+	snapshot, ok := r.original(node)
+	if !ok {
+		return "", false
+	}
+	start, end := r.span(node, snapshot)
+
+	changes := ast.Changes(node)
+	if _, ok := node.(*ast.Document); ok {
+		changes &^= ast.ChangedFields
+	}
+	if changes&ast.ChangedFields == 0 {
+		if changes == 0 && r.unchangedBelow(node) {
+			return r.source[start:end], true
+		}
+		if s, ok := r.splice(node, snapshot, start, end); ok {
+			return s, true
+		}
+	}
+	return indentLines(r.regenerate(node), r.linePrefix(node)), true
+}
+
+// unchangedBelow reports whether every node below node matches its snapshot.
+func (r *MarkdownRenderer) unchangedBelow(node ast.Node) bool {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if _, ok := r.original(child); !ok || ast.Changes(child) != 0 || !r.unchangedBelow(child) {
+			return false
+		}
+	}
+	return true
+}
+
+// splice prints a node whose own syntax is unchanged by copying its source
+// around and between its children, printing each child in turn. Children
+// that kept their original neighbour keep the text in between; others are
+// separated like the first two original children were.
+func (r *MarkdownRenderer) splice(node ast.Node, snapshot *ast.Snapshot, start, end int) (string, bool) {
+	originals := snapshot.Children
+	if len(originals) == 0 {
+		return "", false
+	}
+	spans := make([][2]int, len(originals))
+	index := make(map[ast.Node]int, len(originals))
+	for i, child := range originals {
+		childSnapshot, ok := r.original(child)
+		if !ok {
+			return "", false
+		}
+		spans[i][0], spans[i][1] = r.span(child, childSnapshot)
+		index[child] = i
+
+		// Children that overlap or stray outside node cannot be spliced.
+		from := start
+		if i > 0 {
+			from = spans[i-1][1]
+		}
+		if spans[i][0] < from || spans[i][1] < spans[i][0] || spans[i][1] > end {
+			return "", false
+		}
+	}
+
+	prefix := r.linePrefix(originals[0])
+	separator := ""
+	switch {
+	case len(originals) > 1:
+		separator = r.source[spans[0][1]:spans[1][0]]
+	case originals[0].Type().IsBlock():
+		separator = "\n" + strings.TrimRight(prefix, " \t") + "\n" + prefix
+	}
+
+	var b strings.Builder
+	b.WriteString(r.source[start:spans[0][0]])
+	previous := -1
+	for i, child := range node.Children() {
+		at, isOriginal := index[child]
+		switch {
+		case isOriginal && at == previous+1 && previous >= 0:
+			b.WriteString(r.source[spans[previous][1]:spans[at][0]])
+		case i > 0:
+			b.WriteString(separator)
+		}
+
+		if raw, ok := r.printRaw(child); ok && isOriginal {
+			b.WriteString(raw)
+		} else {
+			b.WriteString(indentLines(r.regenerate(child), prefix))
+		}
+
+		previous = -2
+		if isOriginal {
+			previous = at
+		}
+	}
+	b.WriteString(r.source[spans[len(spans)-1][1]:end])
+	return b.String(), true
+}
+
+// regenerate renders node in the renderer's style, without the markers of
+// the containers around it. Children that come from the source are still
+// printed from it.
+func (r *MarkdownRenderer) regenerate(node ast.Node) string {
+	return r.capture(func() { node.Accept(r) })
+}
+
+// renderChild renders a child of the node being rendered, from the source
+// when it has one.
+func (r *MarkdownRenderer) renderChild(child ast.Node) {
+	if r.source != "" {
+		if raw, ok := r.printRaw(child); ok {
+			r.output.WriteString(dedentLines(raw, r.linePrefix(child)))
+			return
+		}
+	}
+	child.Accept(r)
+}
+
+// walkChildren renders the children of node with renderChild.
+func (r *MarkdownRenderer) walkChildren(node ast.Node) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		r.renderChild(child)
+	}
+}
+
+// linePrefix returns the container markers that start the source lines of
+// node after its first: the text before the block holding node on its first
+// line, with list markers turned into spaces.
+func (r *MarkdownRenderer) linePrefix(node ast.Node) string {
+	for ; node != nil; node = node.Parent() {
+		if !node.Type().IsBlock() {
+			continue
+		}
+		snapshot, ok := r.original(node)
+		if !ok {
+			continue
+		}
+		if _, ok := node.(*ast.Document); ok {
+			return ""
+		}
+
+		start := snapshot.Pos.Start
+		prefix := []byte(r.source[start.Offset-(start.Column-1) : start.Offset])
+		for i, c := range prefix {
+			if c != '>' && c != ' ' && c != '\t' {
+				prefix[i] = ' '
+			}
+		}
+		return string(prefix)
+	}
+	return ""
+}
+
+// indentLines starts every line of s after the first with prefix.
+func indentLines(s, prefix string) string {
+	if prefix == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] == "" {
+			lines[i] = strings.TrimRight(prefix, " \t")
+		} else {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dedentLines removes prefix, or as much of it as a line has, from the lines
+// of s after the first. It undoes indentLines.
+func dedentLines(s, prefix string) string {
+	if prefix == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], prefix) {
+			lines[i] = lines[i][len(prefix):]
+		} else if trimmed := strings.TrimRight(prefix, " \t"); strings.HasPrefix(lines[i], trimmed) {
+			lines[i] = lines[i][len(trimmed):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,125 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+const losslessSource = "#  Title ##\n\n>  Some  __bold__\n>text here.\n\n~~~~ go\nx := 1\n~~~~\n\n* * *\n"
+
+func TestLosslessUnmodified(t *testing.T) {
+	p := parser.New(parser.WithLossless())
+	for _, input := range []string{losslessSource, "", "a\r\n\r\n\r\n  b  \n\n\n", "# x\n\n*[HTML]: Hyper Text\n"} {
+		doc := p.Parse(input)
+		if got := renderer.RenderMarkdown(doc, renderer.WithLossless()); got != input {
+			t.Errorf("expected %q, got %q", input, got)
+		}
+	}
+}
+
+func TestLosslessEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(doc *ast.Document)
+		expected string
+	}{
+		{
+			name: "heading level",
+			edit: func(doc *ast.Document) {
+				doc.FirstChild().(*ast.Heading).Level = 3
+			},
+			expected: "### Title\n\n>  Some  __bold__\n>text here.\n\n~~~~ go\nx := 1\n~~~~\n\n* * *\n",
+		},
+		{
+			name: "text in quote",
+			edit: func(doc *ast.Document) {
+				for content := range ast.OfType[*ast.Content](ast.All(doc)) {
+					if content.Literal == "bold" {
+						content.Literal = "strong*"
+					}
+				}
+			},
+			expected: "#  Title ##\n\n>  Some  __strong\\*__\n>text here.\n\n~~~~ go\nx := 1\n~~~~\n\n* * *\n",
+		},
+		{
+			name: "code block keeps fence",
+			edit: func(doc *ast.Document) {
+				code := doc.Children()[2].(*ast.CodeBlock)
+				code.Info = "rust"
+				code.Language = "rust"
+			},
+			expected: "#  Title ##\n\n>  Some  __bold__\n>text here.\n\n~~~~rust\nx := 1\n~~~~\n\n* * *\n",
+		},
+		{
+			name: "insert and remove blocks",
+			edit: func(doc *ast.Document) {
+				paragraph := ast.NewParagraph()
+				paragraph.AddChild(ast.NewContent("# new"))
+				doc.InsertAfter(doc.FirstChild(), paragraph)
+				doc.RemoveChild(doc.Children()[3])
+			},
+			expected: "#  Title ##\n\n\\# new\n\n>  Some  __bold__\n>text here.\n\n* * *\n",
+		},
+		{
+			name: "append to quote",
+			edit: func(doc *ast.Document) {
+				paragraph := ast.NewParagraph()
+				paragraph.AddChild(ast.NewContent("one"))
+				paragraph.AddChild(ast.NewSoftBreak())
+				paragraph.AddChild(ast.NewContent("two"))
+				doc.Children()[1].AddChild(paragraph)
+			},
+			expected: "#  Title ##\n\n>  Some  __bold__\n>text here.\n>\n> one\n> two\n\n~~~~ go\nx := 1\n~~~~\n\n* * *\n",
+		},
+	}
+
+	p := parser.New(parser.WithLossless())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := p.Parse(losslessSource)
+			tt.edit(doc)
+			if got := renderer.RenderMarkdown(doc, renderer.WithLossless()); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLosslessLinkDestination(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"See <x> [the docs](  /old  'T') now.\n", "See <x> [the docs](</new docs> \"T\") now.\n"},
+		{"Some [a link](http://x.com) and __more__.\n", "Some [a link](</new docs>) and __more__.\n"},
+	}
+
+	p := parser.New(parser.WithLossless())
+	for _, tt := range tests {
+		doc := p.Parse(tt.source)
+		for link := range ast.OfType[*ast.Link](ast.All(doc)) {
+			link.Destination = "/new docs"
+		}
+		if got := renderer.RenderMarkdown(doc, renderer.WithLossless()); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestLosslessRawInput(t *testing.T) {
+	p := parser.New(parser.WithLossless())
+	for _, input := range []string{"\uFEFF# x\n", "a\x00b\n"} {
+		doc := p.Parse(input)
+		if got := renderer.RenderMarkdown(doc, renderer.WithLossless()); got != input {
+			t.Errorf("expected %q, got %q", input, got)
+		}
+	}
+
+	html := renderer.RenderHTML(p.Parse("a\x00b\n"))
+	if html != "<p>a\uFFFDb</p>\n" {
+		t.Errorf("expected the NUL byte to be replaced, got %q", html)
+	}
+}
@@ -0,0 +1,780 @@
+package renderer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// MarkdownRenderer turns a document back into CommonMark. The output parses
+// to the same tree but is normalized: blocks are separated by one blank line,
+// code blocks are fenced, and markers are written in a consistent style set
+// with MarkdownOptions.
+type MarkdownRenderer struct {
+	ast.BaseVisitor
+	output *strings.Builder
+
+	bullet    byte
+	emphasis  byte
+	fenceChar byte
+	setext    bool
+	width     int
+	lossless  bool
+	nodes     map[ast.NodeType]MarkdownNodeRenderer
+	labels    [2]string
+
+	// source is the text of the document being printed in lossless mode.
+	source string
+
+	// indent is the width of the list and quote markers the current block is
+	// nested in, taken off the line width when wrapping.
+	indent int
+	// tight is set while rendering the children of a tight list item.
+	tight bool
+	// delimiters holds the characters of the enclosing emphasis.
+	delimiters []byte
+	// breaks holds the offsets in output of the spaces of text, where the
+	// lines of a paragraph can be broken. Spaces inside code spans and link
+	// destinations are not among them.
+	breaks []int
+}
+
+// MarkdownNodeRenderer renders a node of a type registered with
+// ast.RegisterNodeType back to Markdown.
+type MarkdownNodeRenderer func(r *MarkdownRenderer, node ast.Node)
+
+// MarkdownOption configures optional behaviour of a MarkdownRenderer.
+type MarkdownOption func(*MarkdownRenderer)
+
+// WithBulletChar sets the bullet list marker: '-' (the default), '*' or '+'.
+// Other characters are ignored.
+func WithBulletChar(c byte) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		if c == '-' || c == '*' || c == '+' {
+			r.bullet = c
+		}
+	}
+}
+
+// WithEmphasisChar sets the emphasis delimiter: '*' (the default) or '_'.
+// Strong emphasis uses it doubled. Emphasis inside a word always uses '*',
+// since '_' cannot open or close it there.
+func WithEmphasisChar(c byte) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		if c == '*' || c == '_' {
+			r.emphasis = c
+		}
+	}
+}
+
+// WithSetextHeadings underlines level 1 and 2 headings with === and ---
+// instead of writing ATX # markers. Deeper headings stay ATX.
+func WithSetextHeadings() MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		r.setext = true
+	}
+}
+
+// WithFenceChar sets the code fence character: '`' (the default) or '~'.
+// Backtick fences fall back to '~' when the info string has a backtick.
+func WithFenceChar(c byte) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		if c == '`' || c == '~' {
+			r.fenceChar = c
+		}
+	}
+}
+
+// WithLineWidth reflows paragraphs to fit in width columns, breaking only
+// between words. The default of 0 keeps the line breaks of the source.
+func WithLineWidth(width int) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		r.width = max(width, 0)
+	}
+}
+
+// WithMarkdownNodeRenderer renders nodes of the registered type t with fn.
+// Nodes of a registered type without a renderer only have their children
+// rendered.
+func WithMarkdownNodeRenderer(t ast.NodeType, fn MarkdownNodeRenderer) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		if r.nodes == nil {
+			r.nodes = make(map[ast.NodeType]MarkdownNodeRenderer)
+		}
+		r.nodes[t] = fn
+	}
+}
+
+// WithConflictLabels names the two sides of an ast.Conflict in its markers,
+// which default to "ours" and "theirs".
+func WithConflictLabels(ours, theirs string) MarkdownOption {
+	return func(r *MarkdownRenderer) {
+		r.labels = [2]string{ours, theirs}
+	}
+}
+
+func NewMarkdownRenderer(opts ...MarkdownOption) *MarkdownRenderer {
+	r := &MarkdownRenderer{
+		bullet:    '-',
+		emphasis:  '*',
+		fenceChar: '`',
+		labels:    [2]string{"ours", "theirs"},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func RenderMarkdown(doc *ast.Document, opts ...MarkdownOption) string {
+	return NewMarkdownRenderer(opts...).Render(doc)
+}
+
+// Render returns doc as Markdown ending in a newline, or "" if doc is empty.
+// In lossless mode the document is printed from its source instead.
+func (r *MarkdownRenderer) Render(doc *ast.Document) string {
+	r.output = &strings.Builder{}
+	r.indent, r.tight = 0, false
+	r.delimiters = nil
+	if snapshot, ok := ast.SnapshotOf(doc); ok && r.lossless {
+		return r.renderLossless(doc, snapshot.Source)
+	}
+
+	body := r.renderBlocks(doc, false)
+	if body == "" {
+		return ""
+	}
+	return body + "\n"
+}
+
+// RenderNode returns a single block or inline node as Markdown, without a
+// trailing newline, as it would be written at the top of a document.
+func (r *MarkdownRenderer) RenderNode(node ast.Node) string {
+	if doc, ok := node.(*ast.Document); ok {
+		return strings.TrimSuffix(r.Render(doc), "\n")
+	}
+	r.output = &strings.Builder{}
+	r.indent, r.tight = 0, false
+	r.delimiters = nil
+	return r.regenerate(node)
+}
+
+// WriteString writes s to the output unchanged.
+func (r *MarkdownRenderer) WriteString(s string) {
+	r.output.WriteString(s)
+}
+
+// RenderChildren renders the inline children of node.
+func (r *MarkdownRenderer) RenderChildren(node ast.Node) {
+	r.walkChildren(node)
+}
+
+// capture returns what fn writes instead of adding it to the output.
+func (r *MarkdownRenderer) capture(fn func()) string {
+	s, _ := r.captureBreaks(fn)
+	return s
+}
+
+// captureBreaks returns what fn writes instead of adding it to the output,
+// with the offsets of its breakable spaces.
+func (r *MarkdownRenderer) captureBreaks(fn func()) (string, []int) {
+	saved, savedBreaks := r.output, r.breaks
+	r.output, r.breaks = &strings.Builder{}, nil
+	fn()
+	s, breaks := r.output.String(), r.breaks
+	r.output, r.breaks = saved, savedBreaks
+	return s, breaks
+}
+
+// renderBlocks renders the block children of node, separated by a blank line
+// or, in a tight list, by a single newline. Consecutive abbreviation
+// definitions are kept on consecutive lines.
+func (r *MarkdownRenderer) renderBlocks(node ast.Node, tight bool) string {
+	savedTight := r.tight
+	r.tight = tight
+	defer func() { r.tight = savedTight }()
+
+	var out strings.Builder
+	var prev ast.Node
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		block := r.capture(func() { r.renderChild(child) })
+		if block == "" {
+			continue
+		}
+		if prev != nil {
+			_, prevDef := prev.(*ast.AbbreviationDefinition)
+			_, def := child.(*ast.AbbreviationDefinition)
+			if tight || (prevDef && def) {
+				out.WriteString("\n")
+			} else {
+				out.WriteString("\n\n")
+			}
+		}
+		out.WriteString(block)
+		prev = child
+	}
+	return out.String()
+}
+
+// renderNested renders the children of a container whose lines will be
+// prefixed by indent columns of markers.
+func (r *MarkdownRenderer) renderNested(node ast.Node, indent int, tight bool) string {
+	r.indent += indent
+	defer func() { r.indent -= indent }()
+	return r.renderBlocks(node, tight)
+}
+
+// prefixLines prefixes the first line of s with first and the others with
+// rest. Blank lines get rest without trailing spaces.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *MarkdownRenderer) VisitDocument(node ast.Node) {
+	r.output.WriteString(r.renderBlocks(node, false))
+}
+
+func (r *MarkdownRenderer) VisitBlockQuote(node ast.Node) {
+	r.output.WriteString(prefixLines(r.renderNested(node, 2, false), "> ", "> "))
+}
+
+func (r *MarkdownRenderer) VisitList(node ast.Node) {
+	list := node.(*ast.List)
+
+	// A list right after another of the same kind would join it, so
+	// consecutive lists alternate between two markers.
+	alternate := false
+	for prev := node.PrevSibling(); prev != nil; prev = prev.PrevSibling() {
+		if other, ok := prev.(*ast.List); !ok || other.IsOrdered != list.IsOrdered {
+			break
+		}
+		alternate = !alternate
+	}
+
+	var items []string
+	number := list.StartNum
+	for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := r.listMarker(list, number, alternate)
+		number++
+
+		content := r.renderNested(item, len(marker), list.IsTight)
+		if content == "" {
+			items = append(items, strings.TrimRight(marker, " "))
+			continue
+		}
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+
+	separator := "\n\n"
+	if list.IsTight {
+		separator = "\n"
+	}
+	r.output.WriteString(strings.Join(items, separator))
+}
+
+func (r *MarkdownRenderer) listMarker(list *ast.List, number int, alternate bool) string {
+	if list.IsOrdered {
+		delimiter := byte('.')
+		if list.Delimiter == ')' {
+			delimiter = ')'
+		}
+		if alternate {
+			delimiter = '.' + ')' - delimiter
+		}
+		return strconv.Itoa(number) + string(delimiter) + " "
+	}
+
+	bullet := r.bullet
+	if alternate {
+		bullet = '*'
+		if r.bullet == '*' {
+			bullet = '-'
+		}
+	}
+	return string(bullet) + " "
+}
+
+func (r *MarkdownRenderer) VisitListItem(node ast.Node) {
+	r.output.WriteString(r.renderBlocks(node, r.tight))
+}
+
+func (r *MarkdownRenderer) VisitFencedDiv(node ast.Node) {
+	div := node.(*ast.FencedDiv)
+
+	fence := ":::"
+	if div.Name != "" {
+		fence += " " + div.Name
+	}
+	if len(div.Attributes) > 0 {
+		fence += " " + formatAttributes(div.Attributes)
+	}
+	r.output.WriteString(joinLines(fence, r.renderBlocks(node, false), ":::"))
+}
+
+// formatAttributes writes attrs as a {#id .class key=value} list.
+func formatAttributes(attrs []ast.Attribute) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		switch {
+		case attr.Key == "id" && isAttributeWord(attr.Value):
+			parts[i] = "#" + attr.Value
+		case attr.Key == "class" && isAttributeWord(attr.Value):
+			parts[i] = "." + attr.Value
+		case attr.Value == "":
+			parts[i] = attr.Key
+		case isAttributeWord(attr.Value):
+			parts[i] = attr.Key + "=" + attr.Value
+		case strings.Contains(attr.Value, `"`):
+			parts[i] = attr.Key + "='" + attr.Value + "'"
+		default:
+			parts[i] = attr.Key + `="` + attr.Value + `"`
+		}
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func isAttributeWord(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t=\"'{}")
+}
+
+func joinLines(lines ...string) string {
+	nonEmpty := lines[:0]
+	for _, line := range lines {
+		if line != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+func (r *MarkdownRenderer) VisitCodeBlock(node ast.Node) {
+	codeBlock := node.(*ast.CodeBlock)
+	code := codeBlock.Literal
+	if lines := codeLines(codeBlock); len(lines) > 0 {
+		code = strings.Join(lines, "\n") + "\n"
+	}
+
+	fenceChar, fenceLen := r.fenceChar, 3
+	if r.source != "" && codeBlock.FenceChar != 0 {
+		// An edited block keeps its fence in lossless mode.
+		fenceChar, fenceLen = codeBlock.FenceChar, codeBlock.FenceLen
+	}
+	if fenceChar == '`' && strings.Contains(codeBlock.Info, "`") {
+		fenceChar = '~'
+	}
+	fence := strings.Repeat(string(fenceChar), max(fenceLen, longestRun(code, fenceChar)+1))
+
+	r.output.WriteString(fence)
+	r.output.WriteString(codeBlock.Info)
+	r.output.WriteString("\n")
+	r.output.WriteString(code)
+	if code != "" && !strings.HasSuffix(code, "\n") {
+		r.output.WriteString("\n")
+	}
+	r.output.WriteString(fence)
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+func (r *MarkdownRenderer) VisitHTMLBlock(node ast.Node) {
+	r.output.WriteString(strings.TrimRight(node.(*ast.HTMLBlock).Literal, "\n"))
+}
+
+func (r *MarkdownRenderer) VisitThematicBreak(node ast.Node) {
+	// Without a blank line in between, --- after a paragraph would
+	// underline it as a heading.
+	if _, ok := node.PrevSibling().(*ast.Paragraph); ok && r.tight {
+		r.output.WriteString("***")
+		return
+	}
+	r.output.WriteString("---")
+}
+
+func (r *MarkdownRenderer) VisitHeading(node ast.Node) {
+	heading := node.(*ast.Heading)
+	content, _ := r.renderInlines(node)
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\n", " "))
+
+	if r.setext && heading.Level <= 2 && content != "" {
+		underline := byte('=')
+		if heading.Level == 2 {
+			underline = '-'
+		}
+		r.output.WriteString(escapeLineStart(content))
+		r.output.WriteString("\n")
+		r.output.WriteString(strings.Repeat(string(underline), max(utf8.RuneCountInString(content), 3)))
+		return
+	}
+
+	r.output.WriteString(strings.Repeat("#", heading.Level))
+	if content != "" {
+		if strings.HasSuffix(content, "#") {
+			content = content[:len(content)-1] + `\#`
+		}
+		r.output.WriteString(" " + content)
+	}
+}
+
+func (r *MarkdownRenderer) VisitParagraph(node ast.Node) {
+	content, breaks := r.renderInlines(node)
+	var lines []string
+	start := 0
+	for _, line := range strings.Split(content, "\n") {
+		end := start + len(line)
+		if width := r.width - r.indent; r.width > 0 {
+			var lineBreaks []int
+			for len(breaks) > 0 && breaks[0] < end {
+				lineBreaks = append(lineBreaks, breaks[0]-start)
+				breaks = breaks[1:]
+			}
+			lines = append(lines, wrapLine(line, lineBreaks, max(width, 1))...)
+		} else {
+			lines = append(lines, line)
+		}
+		start = end + 1
+	}
+	for i, line := range lines {
+		lines[i] = escapeLineStart(strings.TrimRight(line, " "))
+	}
+	r.output.WriteString(strings.Join(lines, "\n"))
+}
+
+// wrapLine splits line at the breakable spaces at offsets breaks into lines
+// of at most width columns. Words longer than width get a line of their own.
+func wrapLine(line string, breaks []int, width int) []string {
+	var lines []string
+	var current strings.Builder
+	currentWidth := 0
+	start := 0
+	for i := 0; i <= len(breaks); i++ {
+		end := len(line)
+		if i < len(breaks) {
+			end = breaks[i]
+		}
+		word := line[start:end]
+		start = end + 1
+
+		wordWidth := utf8.RuneCountInString(word)
+		switch {
+		case currentWidth == 0 && word == "":
+			continue
+		case currentWidth == 0:
+		case currentWidth+1+wordWidth > width && word != "":
+			lines = append(lines, current.String())
+			current.Reset()
+			currentWidth = 0
+		default:
+			current.WriteByte(' ')
+			currentWidth++
+		}
+		current.WriteString(word)
+		currentWidth += wordWidth
+	}
+	return append(lines, current.String())
+}
+
+// escapeLineStart escapes a character at the start of a paragraph line that
+// would otherwise open a block, such as the # of an ATX heading.
+func escapeLineStart(line string) string {
+	if line == "" {
+		return line
+	}
+	switch line[0] {
+	case '#', '>', '-', '+', '=':
+		return `\` + line
+	case ':', '~':
+		if strings.HasPrefix(line, strings.Repeat(line[:1], 3)) {
+			return `\` + line
+		}
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		digits := 0
+		for digits < len(line) && digits < 10 && line[digits] >= '0' && line[digits] <= '9' {
+			digits++
+		}
+		if digits < len(line) && digits <= 9 && (line[digits] == '.' || line[digits] == ')') {
+			return line[:digits] + `\` + line[digits:]
+		}
+	}
+	return line
+}
+
+func (r *MarkdownRenderer) VisitTOC(node ast.Node) {
+	r.output.WriteString("[TOC]")
+}
+
+func (r *MarkdownRenderer) VisitAbbreviationDefinition(node ast.Node) {
+	def := node.(*ast.AbbreviationDefinition)
+	r.output.WriteString("*[" + def.Label + "]: " + def.Title)
+}
+
+// renderInlines renders the inline children of node, with soft breaks as
+// newlines unless paragraphs are being reflowed, and returns the offsets of
+// its breakable spaces.
+func (r *MarkdownRenderer) renderInlines(node ast.Node) (string, []int) {
+	return r.captureBreaks(func() { r.walkChildren(node) })
+}
+
+func (r *MarkdownRenderer) VisitContent(node ast.Node) {
+	text := escapeMarkdown(node.(*ast.Content).Literal)
+	for i := 0; i < len(text); i++ {
+		if text[i] == ' ' {
+			r.breaks = append(r.breaks, r.output.Len()+i)
+		}
+	}
+	r.output.WriteString(text)
+}
+
+// escapeMarkdown backslash-escapes the characters of s that could start
+// inline syntax.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '`', '*', '_', '[', ']', '<', '~':
+			b.WriteByte('\\')
+		case '&':
+			// Text after an escape is split into separate nodes, so a
+			// trailing & may still start an entity.
+			if i+1 == len(s) || s[i+1] == '#' || isASCIILetter(s[i+1]) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (r *MarkdownRenderer) VisitSoftBreak(node ast.Node) {
+	if r.width > 0 {
+		r.breaks = append(r.breaks, r.output.Len())
+		r.output.WriteString(" ")
+		return
+	}
+	r.output.WriteString("\n")
+}
+
+func (r *MarkdownRenderer) VisitLineBreak(node ast.Node) {
+	r.output.WriteString("\\\n")
+}
+
+func (r *MarkdownRenderer) VisitCodeSpan(node ast.Node) {
+	literal := node.(*ast.CodeSpan).Literal
+	fence := strings.Repeat("`", longestRun(literal, '`')+1)
+
+	// A space of padding on each side is stripped again by the parser.
+	if literal != "" && (literal[0] == '`' || literal[len(literal)-1] == '`' ||
+		(literal[0] == ' ' && literal[len(literal)-1] == ' ' && strings.Trim(literal, " ") != "")) {
+		literal = " " + literal + " "
+	}
+	r.output.WriteString(fence + literal + fence)
+}
+
+func (r *MarkdownRenderer) VisitHTMLSpan(node ast.Node) {
+	r.output.WriteString(node.(*ast.HTMLSpan).Literal)
+}
+
+func (r *MarkdownRenderer) VisitEmphasis(node ast.Node) {
+	r.writeEmphasis(node, 1)
+}
+
+func (r *MarkdownRenderer) VisitStrong(node ast.Node) {
+	r.writeEmphasis(node, 2)
+}
+
+func (r *MarkdownRenderer) VisitStrikethrough(node ast.Node) {
+	r.output.WriteString("~~")
+	r.walkChildren(node)
+	r.output.WriteString("~~")
+}
+
+func (r *MarkdownRenderer) writeEmphasis(node ast.Node, count int) {
+	c := r.emphasis
+	switch n := len(r.delimiters); {
+	case isIntraword(node):
+		c = '*'
+	case n > 0 && r.delimiters[n-1] == c && (node.PrevSibling() == nil || node.NextSibling() == nil):
+		// Nested emphasis touching its parent's delimiters uses the other
+		// character, so that *(*a*)* is not read as **a**.
+		c = otherEmphasisChar(c)
+	}
+
+	delimiter := strings.Repeat(string(c), count)
+	r.delimiters = append(r.delimiters, c)
+	r.output.WriteString(delimiter)
+	r.walkChildren(node)
+	r.output.WriteString(delimiter)
+	r.delimiters = r.delimiters[:len(r.delimiters)-1]
+}
+
+func otherEmphasisChar(c byte) byte {
+	if c == '*' {
+		return '_'
+	}
+	return '*'
+}
+
+// isIntraword reports whether the delimiters of node, or of the emphasis it
+// starts or ends, touch a letter or digit.
+func isIntraword(node ast.Node) bool {
+	for n := node; ; n = n.Parent() {
+		if prev, ok := n.PrevSibling().(*ast.Content); ok {
+			if r, _ := utf8.DecodeLastRuneInString(prev.Literal); isWordRune(r) {
+				return true
+			}
+			break
+		}
+		if n.PrevSibling() != nil || !isEmphasis(n.Parent()) {
+			break
+		}
+	}
+	for n := node; ; n = n.Parent() {
+		if next, ok := n.NextSibling().(*ast.Content); ok {
+			r, _ := utf8.DecodeRuneInString(next.Literal)
+			return isWordRune(r)
+		}
+		if n.NextSibling() != nil || !isEmphasis(n.Parent()) {
+			break
+		}
+	}
+	return false
+}
+
+func isEmphasis(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Emphasis, *ast.Strong:
+		return true
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (r *MarkdownRenderer) VisitLink(node ast.Node) {
+	link := node.(*ast.Link)
+	r.output.WriteString("[")
+	r.walkChildren(node)
+	r.output.WriteString("](" + formatDestination(link.Destination, link.Title) + ")")
+}
+
+func (r *MarkdownRenderer) VisitImage(node ast.Node) {
+	image := node.(*ast.Image)
+	r.output.WriteString("![")
+	if node.FirstChild() != nil {
+		r.walkChildren(node)
+	} else {
+		// The parser drops line breaks in alt text, so it is never wrapped.
+		r.output.WriteString(escapeMarkdown(image.AltText))
+	}
+	r.output.WriteString("](" + formatDestination(image.Destination, image.Title) + ")")
+}
+
+// formatDestination writes a link destination and optional title, using the
+// <...> form for destinations that cannot be written bare.
+func formatDestination(destination, title string) string {
+	if destination == "" || strings.ContainsAny(destination, " \t\n<>") || !balancedParens(destination) {
+		destination = "<" + strings.NewReplacer(`\`, `\\`, "<", `\<`, ">", `\>`).Replace(destination) + ">"
+	} else {
+		destination = strings.ReplaceAll(destination, `\`, `\\`)
+	}
+	if title == "" {
+		return destination
+	}
+	return destination + ` "` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(title) + `"`
+}
+
+func balancedParens(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func (r *MarkdownRenderer) VisitAbbreviation(node ast.Node) {
+	r.walkChildren(node)
+}
+
+func (r *MarkdownRenderer) VisitNode(node ast.Node) {
+	if fn, ok := r.nodes[node.Type()]; ok {
+		fn(r, node)
+		return
+	}
+	if conflict, ok := node.(*ast.Conflict); ok {
+		r.writeConflict(conflict)
+		return
+	}
+	if node.Type().IsBlock() {
+		r.output.WriteString(r.renderBlocks(node, r.tight))
+		return
+	}
+	r.walkChildren(node)
+}
+
+// writeConflict writes the two sides of a conflict between markers, as git
+// does:
+//
+//	<<<<<<< ours
+//	our blocks
+//	=======
+//	their blocks
+//	>>>>>>> theirs
+func (r *MarkdownRenderer) writeConflict(conflict *ast.Conflict) {
+	lines := []string{"<<<<<<< " + r.labels[0]}
+	for i, side := range [][]ast.Node{conflict.Ours, conflict.Theirs} {
+		if i > 0 {
+			lines = append(lines, "=======")
+		}
+		var blocks []string
+		for _, block := range side {
+			if s := r.capture(func() { r.renderChild(block) }); s != "" {
+				blocks = append(blocks, s)
+			}
+		}
+		if len(blocks) > 0 {
+			lines = append(lines, strings.Join(blocks, "\n\n"))
+		}
+	}
+	lines = append(lines, ">>>>>>> "+r.labels[1])
+	r.output.WriteString(strings.Join(lines, "\n"))
+}
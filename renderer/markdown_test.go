@@ -0,0 +1,187 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []renderer.MarkdownOption
+		expected string
+	}{
+		{"empty", "", nil, ""},
+		{"atx heading", "# Title #\n\n###    Sub", nil, "# Title\n\n### Sub\n"},
+		{"setext heading", "# Title\n\n## Sub\n\n### Deep", []renderer.MarkdownOption{renderer.WithSetextHeadings()},
+			"Title\n=====\n\nSub\n---\n\n### Deep\n"},
+		{"heading trailing hash", "# C\\#", nil, "# C\\#\n"},
+		{"paragraph breaks", "a\nb\\\nc", nil, "a\nb\\\nc\n"},
+		{"escapes", "\\*a\\* \\_b\\_ \\[c\\] \\<d> \\&amp;", nil, "\\*a\\* \\_b\\_ \\[c\\] \\<d> \\&amp;\n"},
+		{"line start escapes", "a\n\\# b\n\\- c\n1\\. d", nil, "a\n\\# b\n\\- c\n1\\. d\n"},
+		{"emphasis", "*a* __b__ ***c***", nil, "*a* **b** *__c__*\n"},
+		{"underscore emphasis", "*a* **b** a*b*c", []renderer.MarkdownOption{renderer.WithEmphasisChar('_')},
+			"_a_ __b__ a*b*c\n"},
+		{"code span", "`` a`b `` ` `` ` `` `a ``", nil, "``a`b`` ``` `` ``` `` `a ``\n"},
+		{"fenced code", "~~~ go {linenos=true}\nx\n~~~", nil, "```go {linenos=true}\nx\n```\n"},
+		{"fence char", "```sh\nls\n```", []renderer.MarkdownOption{renderer.WithFenceChar('~')}, "~~~sh\nls\n~~~\n"},
+		{"block quote", "> a\nb", nil, "> a\n> b\n"},
+		{"fenced div", "::: note {#x .y k=\"a b\"}\ntext\n:::", nil, "::: note {#x .y k=\"a b\"}\ntext\n:::\n"},
+		{"thematic break", "a\n\n* * *", nil, "a\n\n---\n"},
+		{"toc", "[TOC]", nil, "[TOC]\n"},
+		{"abbreviation", "the HTML spec\n\n*[HTML]: Hyper Text Markup Language", nil,
+			"the HTML spec\n\n*[HTML]: Hyper Text Markup Language\n"},
+		{"abbreviation definitions in place", "*[HTML]: Hyper Text\n*[CSS]: Style sheets\n\nUse HTML here.\n\n" +
+			"*[XML]: Extensible Markup Language\n\nThe end.", nil,
+			"*[HTML]: Hyper Text\n*[CSS]: Style sheets\n\nUse HTML here.\n\n*[XML]: Extensible Markup Language\n\nThe end.\n"},
+		{"line width", "one two three four five six seven\neight", []renderer.MarkdownOption{renderer.WithLineWidth(12)},
+			"one two\nthree four\nfive six\nseven eight\n"},
+		{"line width in quote", "> one two three", []renderer.MarkdownOption{renderer.WithLineWidth(9)},
+			"> one two\n> three\n"},
+		{"line width keeps code", "a `b c d e` f", []renderer.MarkdownOption{renderer.WithLineWidth(3)},
+			"a\n`b c d e`\nf\n"},
+		{"line width escapes", "a - b", []renderer.MarkdownOption{renderer.WithLineWidth(1)}, "a\n\\-\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderer.RenderMarkdown(parser.Parse(tt.input), tt.opts...)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownLinks(t *testing.T) {
+	link := func(destination, title string) ast.Node {
+		link := ast.NewLink(destination, title)
+		link.AddChild(ast.NewContent("a"))
+		return link
+	}
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{link("/url", ""), "[a](/url)\n"},
+		{link("/url", `say "hi"`), "[a](/url \"say \\\"hi\\\"\")\n"},
+		{link("", ""), "[a](<>)\n"},
+		{link("/a b", ""), "[a](</a b>)\n"},
+		{link("/a(b", ""), "[a](</a(b>)\n"},
+		{link(`/a\b`, ""), "[a](/a\\\\b)\n"},
+		{ast.NewImage("/i.png", "", "a *b*"), "![a \\*b\\*](/i.png)\n"},
+	}
+
+	for _, tt := range tests {
+		paragraph := ast.NewParagraph()
+		paragraph.AddChild(tt.node)
+		doc := ast.NewDocument()
+		doc.AddChild(paragraph)
+
+		if got := renderer.RenderMarkdown(doc); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestRenderMarkdownCodeFences(t *testing.T) {
+	codeBlock := func(info string, lines ...string) ast.Node {
+		codeBlock := ast.NewCodeBlock(true)
+		codeBlock.Info = info
+		for _, line := range lines {
+			codeBlock.AddChild(ast.NewContent(line))
+		}
+		return codeBlock
+	}
+
+	tests := []struct {
+		node     ast.Node
+		opts     []renderer.MarkdownOption
+		expected string
+	}{
+		{codeBlock("", "```", "x"), nil, "````\n```\nx\n````\n"},
+		{codeBlock("a`b", "x"), nil, "~~~a`b\nx\n~~~\n"},
+		{codeBlock("", "~~~~"), []renderer.MarkdownOption{renderer.WithFenceChar('~')}, "~~~~~\n~~~~\n~~~~~\n"},
+		{codeBlock("go"), nil, "```go\n```\n"},
+	}
+
+	for _, tt := range tests {
+		doc := ast.NewDocument()
+		doc.AddChild(tt.node)
+		if got := renderer.RenderMarkdown(doc, tt.opts...); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+// markdownList builds a list of one-paragraph items.
+func markdownList(ordered, tight bool, items ...string) *ast.List {
+	list := ast.NewList(ordered)
+	list.IsTight = tight
+	for _, text := range items {
+		paragraph := ast.NewParagraph()
+		paragraph.AddChild(ast.NewContent(text))
+		item := ast.NewListItem(0)
+		item.AddChild(paragraph)
+		list.AddChild(item)
+	}
+	return list
+}
+
+func TestRenderMarkdownLists(t *testing.T) {
+	nested := markdownList(false, true, "a", "b")
+	nested.FirstChild().AddChild(markdownList(true, true, "c"))
+
+	ordered := markdownList(true, false, "x", "y")
+	ordered.StartNum = 9
+	ordered.Delimiter = ')'
+
+	doc := ast.NewDocument()
+	doc.AddChild(nested)
+	doc.AddChild(markdownList(false, true, "d"))
+	doc.AddChild(ordered)
+
+	expected := "- a\n  1. c\n- b\n\n* d\n\n9) x\n\n10) y\n"
+	if got := renderer.RenderMarkdown(doc); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	expected = "+ a\n  1. c\n+ b\n\n* d\n\n9) x\n\n10) y\n"
+	if got := renderer.RenderMarkdown(doc, renderer.WithBulletChar('+')); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRenderMarkdownNULInText(t *testing.T) {
+	doc, err := ast.Build().Paragraph("a\x00b c").Document()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := renderer.RenderMarkdown(doc); got != "a\x00b c\n" {
+		t.Errorf("expected the NUL byte to be kept, got %q", got)
+	}
+	if got := renderer.RenderMarkdown(doc, renderer.WithLineWidth(3)); got != "a\x00b\nc\n" {
+		t.Errorf("expected the NUL byte to be kept when wrapping, got %q", got)
+	}
+}
+
+func TestRenderMarkdownConflict(t *testing.T) {
+	base := parser.Parse("# Title\n\nOne two three.\n\nFour five six.")
+	ours := parser.Parse("# Title\n\nOne two three ours.\n\nFour five six.")
+	theirs := parser.Parse("#  Title\n\nOne two three theirs.\n\nFour five\nsix.\n\n## Added")
+	merged, conflicts := ast.Merge(base, ours, theirs)
+	if conflicts != 1 {
+		t.Fatalf("expected 1 conflict, got %d", conflicts)
+	}
+
+	expected := "# Title\n\n<<<<<<< a.md\nOne two three ours.\n=======\nOne two three theirs.\n>>>>>>> b.md\n\n" +
+		"Four five\nsix.\n\n## Added\n"
+	if got := renderer.RenderMarkdown(merged, renderer.WithConflictLabels("a.md", "b.md")); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
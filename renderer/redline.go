@@ -0,0 +1,459 @@
+package renderer
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rybkr/markee/ast"
+)
+
+// WithRedline marks up the rendered document with its changes from old, for
+// reviewing a revision. Added blocks are wrapped in <ins> and removed blocks
+// are shown in <del> where they were; a moved block shows as removed from
+// its old place and added in the new one. Changed paragraphs and headings
+// mark the words that were inserted and deleted, while other changed blocks
+// show as removed and added again. Differences in formatting alone are not
+// marked, as with ast.Diff.
+func WithRedline(old *ast.Document) HTMLOption {
+	return func(r *HTMLRenderer) {
+		r.redlineBase = old
+	}
+}
+
+// redline holds the edits between two documents by the nodes of the new one.
+type redline struct {
+	inserted map[ast.Node]bool
+	changed  map[ast.Node]ast.Node
+	deleted  map[ast.Node][]ast.Edit
+	// words is the diff of the paragraph or heading being written.
+	words *wordDiff
+}
+
+func newRedline(edits []ast.Edit) *redline {
+	rl := &redline{
+		inserted: make(map[ast.Node]bool),
+		changed:  make(map[ast.Node]ast.Node),
+		deleted:  make(map[ast.Node][]ast.Edit),
+	}
+	for _, edit := range edits {
+		switch edit.Kind {
+		case ast.Inserted:
+			rl.inserted[edit.New] = true
+		case ast.Changed:
+			rl.changed[edit.New] = edit.Old
+		case ast.Moved:
+			rl.inserted[edit.New] = true
+			rl.deleted[edit.Parent] = append(rl.deleted[edit.Parent], edit)
+		case ast.Deleted:
+			rl.deleted[edit.Parent] = append(rl.deleted[edit.Parent], edit)
+		}
+	}
+	return rl
+}
+
+// walkChildren renders the children of node, marking their changes in
+// redline mode.
+func (r *HTMLRenderer) walkChildren(node ast.Node) {
+	if r.redline == nil {
+		ast.WalkChildren(r, node)
+		return
+	}
+	if r.redline.words != nil {
+		r.walkWordDiff(node)
+		return
+	}
+	if old, ok := r.redline.changed[node]; ok && !node.Type().IsContainer() {
+		r.writeInlineDiff(old, node)
+		return
+	}
+
+	children := node.Children()
+	for i, child := range children {
+		r.writeDeleted(node, i)
+		old, changed := r.redline.changed[child]
+		switch {
+		case r.redline.inserted[child]:
+			r.writeMarked("ins", child)
+		case changed && child.Type().IsLeaf() && !hasInlines(child):
+			r.writeMarked("del", old)
+			r.writeMarked("ins", child)
+		default:
+			child.Accept(r)
+		}
+	}
+	r.writeDeleted(node, len(children))
+}
+
+// writeDeleted writes the blocks deleted from before the child at index of
+// parent.
+func (r *HTMLRenderer) writeDeleted(parent ast.Node, index int) {
+	for _, edit := range r.redline.deleted[parent] {
+		if edit.Index == index {
+			r.writeMarked("del", edit.Old)
+		}
+	}
+}
+
+// writeMarked writes a whole block inside an <ins> or <del> element. List
+// items keep their <li> outside it, since a list can only hold items.
+func (r *HTMLRenderer) writeMarked(tag string, node ast.Node) {
+	saved := r.redline
+	r.redline = nil
+	defer func() { r.redline = saved }()
+
+	if _, ok := node.(*ast.ListItem); ok {
+		r.output.WriteString("<li")
+		r.WriteAttributes(node)
+		r.output.WriteString("><" + tag + ">")
+		r.walkChildren(node)
+		r.output.WriteString("</" + tag + "></li>\n")
+		return
+	}
+	r.output.WriteString("<" + tag + ">\n")
+	node.Accept(r)
+	r.output.WriteString("</" + tag + ">\n")
+}
+
+// hasInlines reports whether changes to node are marked word by word.
+func hasInlines(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Paragraph, *ast.Heading:
+		return true
+	}
+	return false
+}
+
+// token is a word of text with the white space before it, or an inline
+// node such as a code span that is compared as a whole. Words inside a link
+// are keyed by its destination too, so a changed destination marks them.
+type token struct {
+	word
+	node ast.Node
+	key  string
+	// leaf is the text node or inline node the token comes from, and
+	// context the markup it sits in.
+	leaf    ast.Node
+	context string
+	// gap is the white space before the token, including that at the end
+	// of earlier text nodes.
+	gap string
+}
+
+// tokenizer flattens the text of an inline container into tokens, across
+// emphasis, links and other inline markup, so that changes in formatting
+// alone leave the tokens as they were.
+type tokenizer struct {
+	tokens []token
+	// ends holds, for each text node and compared inline node, the end of
+	// its tokens; trailing holds the white space after a text node's last
+	// word.
+	ends     map[ast.Node]int
+	trailing map[ast.Node]string
+	pending  string
+}
+
+func tokenize(node ast.Node) *tokenizer {
+	t := &tokenizer{ends: make(map[ast.Node]int), trailing: make(map[ast.Node]string)}
+	t.add(node, "", "")
+	return t
+}
+
+func (t *tokenizer) add(node ast.Node, link, context string) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch c := child.(type) {
+		case *ast.Content:
+			words, trailing := splitWords(c.Literal)
+			for _, w := range words {
+				t.tokens = append(t.tokens, token{word: w, key: link + "word " + w.text, leaf: c, context: context, gap: t.pending + w.space})
+				t.pending = ""
+			}
+			t.trailing[c] = trailing
+			t.pending += trailing
+		case *ast.SoftBreak:
+			t.pending += "\n"
+			continue
+		case *ast.Link:
+			t.add(c, link+"link "+c.Destination+" "+c.Title+"\x00", context+" link")
+			continue
+		case *ast.Emphasis, *ast.Strong, *ast.Strikethrough, *ast.Abbreviation:
+			t.add(c, link, context+" "+c.Type().String())
+			continue
+		default:
+			t.tokens = append(t.tokens, token{node: c, key: link + inlineKey(c), leaf: c, context: context, gap: t.pending})
+			t.pending = ""
+		}
+		t.ends[child] = len(t.tokens)
+	}
+}
+
+// inlineKey identifies the inline nodes that are compared as a whole.
+func inlineKey(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.Image:
+		return "image " + n.Destination + " " + n.Title + " " + n.AltText
+	case *ast.CodeSpan:
+		return "code " + n.Literal
+	case *ast.HTMLSpan:
+		return "html " + n.Literal
+	}
+	return node.Type().String()
+}
+
+// wordDiff is the word-by-word difference between the text of a changed
+// paragraph or heading and its old version, written out while the new one
+// is rendered.
+type wordDiff struct {
+	old, new *tokenizer
+	ops      []diffOp
+	// anchors holds, for each op, the new token it is written with: its own
+	// for tokens in both versions and inserted ones, and the next one for
+	// deleted ones.
+	anchors  []int
+	next     int
+	previous string
+	// owed is the space before deleted tokens that was left out because
+	// the output already ended in white space; it goes after them instead.
+	owed string
+}
+
+// writeInlineDiff writes the children of node marked with their changes from
+// those of old. The words of both are compared as one run of text, so words
+// that only moved in or out of emphasis or a link are left unmarked, and
+// deleted words come before the words inserted in their place.
+func (r *HTMLRenderer) writeInlineDiff(old, node ast.Node) {
+	d := &wordDiff{old: tokenize(old), new: tokenize(node)}
+	d.ops = diffSequence(len(d.old.tokens), len(d.new.tokens), func(i, j int) bool {
+		return d.old.tokens[i].key == d.new.tokens[j].key
+	})
+	d.placeDeletions()
+	d.setAnchors()
+
+	r.redline.words = d
+	r.walkWordDiff(node)
+	r.writeWords(len(d.new.tokens) + 1)
+	r.redline.words = nil
+}
+
+// placeDeletions moves the deleted tokens of each changed run next to the
+// inserted tokens that replace them. When the insertions span several text
+// nodes, the deletions go before the first whose markup matches theirs, so a
+// word replaced outside of emphasis is not shown inside it.
+func (d *wordDiff) placeDeletions() {
+	for start := 0; start < len(d.ops); {
+		if d.ops[start].tag() == "" {
+			start++
+			continue
+		}
+		end := start
+		for end < len(d.ops) && d.ops[end].tag() != "" {
+			end++
+		}
+		run := d.ops[start:end]
+		split := slices.IndexFunc(run, func(op diffOp) bool { return op.new >= 0 })
+		if split > 0 {
+			deleted := slices.Clone(run[:split])
+			inserted := slices.Clone(run[split:])
+			context := d.old.tokens[deleted[0].old].context
+			at := 0
+			for i, op := range inserted {
+				t := d.new.tokens[op.new]
+				if (i == 0 || t.leaf != d.new.tokens[inserted[i-1].new].leaf) && t.context == context {
+					at = i
+					break
+				}
+			}
+			copy(run, inserted[:at])
+			copy(run[at:], deleted)
+			copy(run[at+len(deleted):], inserted[at:])
+		}
+		start = end
+	}
+}
+
+// setAnchors sets the token each op is written with. Deleted tokens are
+// written before the next token, unless nothing is inserted in their place
+// and only the token before them shares their markup.
+func (d *wordDiff) setAnchors() {
+	d.anchors = make([]int, len(d.ops))
+	replaced := make([]bool, len(d.ops))
+	next, inserted := len(d.new.tokens), false
+	for i := len(d.ops) - 1; i >= 0; i-- {
+		if d.ops[i].new >= 0 {
+			next, inserted = d.ops[i].new, d.ops[i].old < 0
+		}
+		d.anchors[i], replaced[i] = next, inserted
+	}
+
+	previous := -1
+	for i, op := range d.ops {
+		if op.new >= 0 {
+			previous = op.new
+			continue
+		}
+		if replaced[i] || previous < 0 {
+			continue
+		}
+		context := d.old.tokens[op.old].context
+		if d.new.tokens[previous].context == context &&
+			(d.anchors[i] == len(d.new.tokens) || d.new.tokens[d.anchors[i]].context != context) {
+			d.anchors[i] = previous
+		}
+	}
+}
+
+// walkWordDiff renders the children of an inline container of a changed
+// paragraph or heading, writing its text with the words marked.
+func (r *HTMLRenderer) walkWordDiff(node ast.Node) {
+	d := r.redline.words
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		end, ok := d.new.ends[child]
+		if !ok {
+			child.Accept(r)
+			continue
+		}
+		r.writeWords(end)
+		r.output.WriteString(escapeHTML(d.new.trailing[child]))
+	}
+}
+
+// writeWords writes the ops up to the new token at end. Each run of changed
+// tokens is marked as a whole, with the space before it left outside, and
+// tokens inserted in place of deleted ones follow them without a space.
+func (r *HTMLRenderer) writeWords(end int) {
+	d := r.redline.words
+	for d.next < len(d.ops) && d.anchors[d.next] < end {
+		start, tag := d.next, d.ops[d.next].tag()
+		for d.next < len(d.ops) && d.anchors[d.next] < end && d.ops[d.next].tag() == tag {
+			d.next++
+		}
+
+		for i, op := range d.ops[start:d.next] {
+			t := d.new.tokens[max(op.new, 0)]
+			if op.new < 0 {
+				t = d.old.tokens[op.old]
+			}
+			r.writeSpace(t, i == 0, tag)
+			if i == 0 && tag != "" {
+				r.output.WriteString("<" + tag + ">")
+			}
+			r.writeToken(t)
+		}
+		if tag != "" {
+			r.output.WriteString("</" + tag + ">")
+		}
+		d.previous = tag
+	}
+}
+
+// writeSpace writes the white space before t. Tokens inserted in place of
+// deleted ones follow them directly, and deleted tokens take the space before
+// them from the old text, since in the new one it may end an earlier node.
+func (r *HTMLRenderer) writeSpace(t token, first bool, tag string) {
+	d := r.redline.words
+	switch {
+	case first && tag == "ins" && d.previous == "del":
+	case first && tag == "del":
+		out := r.output.String()
+		for strings.HasSuffix(out, ">") {
+			out = out[:strings.LastIndexByte(out, '<')]
+		}
+		if out != "" && !unicode.IsSpace(rune(out[len(out)-1])) {
+			r.output.WriteString(escapeHTML(t.gap))
+		} else {
+			d.owed = t.gap
+		}
+	case t.space == "":
+		r.output.WriteString(escapeHTML(d.owed))
+		d.owed = ""
+	default:
+		r.output.WriteString(escapeHTML(t.space))
+		d.owed = ""
+	}
+}
+
+func (r *HTMLRenderer) writeToken(t token) {
+	if t.node == nil {
+		r.output.WriteString(escapeHTML(t.text))
+		return
+	}
+	saved := r.redline
+	r.redline = nil
+	t.node.Accept(r)
+	r.redline = saved
+}
+
+// word is a word of text with the white space before it.
+type word struct {
+	space, text string
+}
+
+// splitWords splits s into words, returning the white space after the last
+// one separately.
+func splitWords(s string) ([]word, string) {
+	var words []word
+	for {
+		start := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			return words, s
+		}
+		end := strings.IndexFunc(s[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(s) - start
+		}
+		words = append(words, word{space: s[:start], text: s[start : start+end]})
+		s = s[start+end:]
+	}
+}
+
+// diffOp pairs an item of an old sequence with one of a new sequence. An
+// index of -1 marks the other item as deleted or inserted.
+type diffOp struct {
+	old, new int
+}
+
+// tag returns the element marking op: "del", "ins", or "" for items in both
+// sequences.
+func (op diffOp) tag() string {
+	switch {
+	case op.old < 0:
+		return "ins"
+	case op.new < 0:
+		return "del"
+	}
+	return ""
+}
+
+// diffSequence aligns two sequences on a longest common subsequence of equal
+// items. Deleted items come before the items inserted in their place.
+func diffSequence(n, m int, equal func(i, j int) bool) []diffOp {
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(i, j) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	for i, j := 0, 0; i < n || j < m; {
+		switch {
+		case i < n && j < m && equal(i, j):
+			ops = append(ops, diffOp{i, j})
+			i, j = i+1, j+1
+		case j == m || (i < n && table[i+1][j] >= table[i][j+1]):
+			ops = append(ops, diffOp{i, -1})
+			i++
+		default:
+			ops = append(ops, diffOp{-1, j})
+			j++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,75 @@
+package renderer_test
+
+import (
+	"testing"
+
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
+)
+
+func TestRedline(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{"unchanged", "# Title\n\nSome text\nhere.", "#  Title #\n\nSome  text here.",
+			"<h1>Title</h1>\n<p>Some  text here.</p>\n"},
+		{"words", "Employees may work **from home** two days a week.",
+			"Staff may work **from home or abroad** three days a week.",
+			"<p><del>Employees</del><ins>Staff</ins> may work <strong>from home <ins>or abroad</ins></strong> " +
+				"<del>two</del><ins>three</ins> days a week.</p>\n"},
+		{"markup removed", "The **quick** brown fox.", "The quick brown fox.",
+			"<p>The quick brown fox.</p>\n"},
+		{"markup and words", "The **quick** brown fox.", "The slow *brown* fox.",
+			"<p>The <del>quick</del><ins>slow</ins> <em>brown</em> fox.</p>\n"},
+		{"deleted before markup", "a b **c**", "a **c**", "<p>a <del>b</del> <strong>c</strong></p>\n"},
+		{"inline nodes", "See `a` and `b` now.", "See `a` and `c` now.",
+			"<p>See <code>a</code> and <del><code>b</code></del><ins><code>c</code></ins> now.</p>\n"},
+		{"escaped", "a < b", "a < c", "<p>a &lt; <del>b</del><ins>c</ins></p>\n"},
+		{"blocks", "# Policy\n\nOld note.\n\nKeep this.", "# New Policy\n\nKeep this.\n\nAdded.",
+			"<h1><ins>New</ins> Policy</h1>\n<del>\n<p>Old note.</p>\n</del>\n<p>Keep this.</p>\n" +
+				"<ins>\n<p>Added.</p>\n</ins>\n"},
+		{"moved", "First one.\n\nSecond one.", "Second one.\n\nFirst one.",
+			"<del>\n<p>First one.</p>\n</del>\n<p>Second one.</p>\n<ins>\n<p>First one.</p>\n</ins>\n"},
+		{"code block", "~~~ go\nx := 1\n~~~", "```go\nx := 2\n```",
+			"<del>\n<pre><code class=\"language-go\">x := 1</code></pre>\n</del>\n" +
+				"<ins>\n<pre><code class=\"language-go\">x := 2</code></pre>\n</ins>\n"},
+		{"nested", "> Quoted advice\n> here.", "> Quoted advice\n> over here.",
+			"<blockquote>\n<p>Quoted advice\n<ins>over</ins> here.</p>\n</blockquote>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, doc := parser.Parse(tt.old), parser.Parse(tt.new)
+			if html := renderer.RenderHTML(doc, renderer.WithRedline(old)); html != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, html)
+			}
+		})
+	}
+}
+
+func TestRedlineListItems(t *testing.T) {
+	list := func(items ...string) *ast.Document {
+		l := ast.NewList(false)
+		for _, item := range items {
+			li := ast.NewListItem(2)
+			p := ast.NewParagraph()
+			p.AddChild(ast.NewContent(item))
+			li.AddChild(p)
+			l.AddChild(li)
+		}
+		doc := ast.NewDocument()
+		doc.AddChild(l)
+		return doc
+	}
+
+	old := list("Laptops", "Desktops", "Phones")
+	html := renderer.RenderHTML(list("Laptops", "Phones", "Tablets"), renderer.WithRedline(old))
+	expected := "<ul>\n<li><p>Laptops</p>\n</li>\n<li><del><p>Desktops</p>\n</del></li>\n<li><p>Phones</p>\n</li>\n" +
+		"<li><ins><p>Tablets</p>\n</ins></li>\n</ul>\n"
+	if html != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, html)
+	}
+}
@@ -0,0 +1,16 @@
+// Package renderer turns a parsed document back into text. HTMLRenderer
+// produces CommonMark-conformant HTML and is configured with HTMLOptions;
+// MarkdownRenderer writes the document back out as normalized CommonMark.
+package renderer
+
+import (
+    "github.com/rybkr/markee/ast"
+)
+
+// Renderer renders a whole document to a string.
+type Renderer interface {
+    Render(doc *ast.Document) string
+}
+
+var _ Renderer = (*HTMLRenderer)(nil)
+var _ Renderer = (*MarkdownRenderer)(nil)
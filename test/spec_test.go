@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"markee/internal/parser"
-	"markee/internal/renderer"
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"github.com/rybkr/markee/renderer"
 	"os"
 	"path/filepath"
 	"strings"
@@ -54,6 +55,7 @@ func TestMain(m *testing.M) {
 func TestCommonmarkCompliance(t *testing.T) {
 	examples := loadSpec(t)
 	passed, failed := 0, 0
+	commonMark := parser.New(parser.WithCommonMark())
 
 	for _, ex := range examples {
 		if categoryFilter != "" && ex.Section != categoryFilter {
@@ -62,7 +64,7 @@ func TestCommonmarkCompliance(t *testing.T) {
 
 		ex := ex
 		t.Run(fmt.Sprintf("%s#%d", ex.Section, ex.Example), func(t *testing.T) {
-			doc := parser.Parse(ex.Markdown)
+			doc := commonMark.Parse(ex.Markdown)
 			got := renderer.RenderHTML(doc)
 
 			if got != ex.HTML {
@@ -78,3 +80,66 @@ func TestCommonmarkCompliance(t *testing.T) {
 
 	t.Logf("Passed %d, Failed %d, Total %d", passed, failed, passed+failed)
 }
+
+// TestSpecTreesValid checks that every example parses to a tree that passes
+// ast.Validate, with and without extensions, whether or not its HTML matches.
+func TestSpecTreesValid(t *testing.T) {
+	parsers := []*parser.Parser{parser.New(), parser.New(parser.WithCommonMark())}
+
+	for _, ex := range loadSpec(t) {
+		for _, p := range parsers {
+			if err := ast.Validate(p.Parse(ex.Markdown)); err != nil {
+				t.Errorf("example %d parses to an invalid tree\nMarkdown:\n%s\n%v",
+					ex.Example, showWhitespace(ex.Markdown), err,
+				)
+			}
+		}
+	}
+}
+
+// TestMarkdownRoundTrip checks that every example the parser gets right
+// still renders to the same HTML after a trip through the Markdown renderer,
+// and that formatting the output again leaves it unchanged.
+func TestMarkdownRoundTrip(t *testing.T) {
+	commonMark := parser.New(parser.WithCommonMark())
+
+	for _, ex := range loadSpec(t) {
+		doc := commonMark.Parse(ex.Markdown)
+		if renderer.RenderHTML(doc) != ex.HTML {
+			continue
+		}
+
+		formatted := renderer.RenderMarkdown(doc)
+		if got := renderer.RenderHTML(commonMark.Parse(formatted)); got != ex.HTML {
+			t.Errorf("example %d changed after formatting\nMarkdown:\n%s\nFormatted:\n%s\nExpected:\n%s\nGot:\n%s",
+				ex.Example, showWhitespace(ex.Markdown), showWhitespace(formatted), showWhitespace(ex.HTML), showWhitespace(got),
+			)
+			continue
+		}
+		if again := renderer.RenderMarkdown(commonMark.Parse(formatted)); again != formatted {
+			t.Errorf("example %d is not stable\nFormatted:\n%s\nAgain:\n%s",
+				ex.Example, showWhitespace(formatted), showWhitespace(again),
+			)
+		}
+	}
+}
+
+// TestLosslessRoundTrip checks that every example is printed back byte for
+// byte from a lossless parse, with and without extensions.
+func TestLosslessRoundTrip(t *testing.T) {
+	parsers := []*parser.Parser{
+		parser.New(parser.WithLossless()),
+		parser.New(parser.WithLossless(), parser.WithCommonMark()),
+	}
+
+	for _, ex := range loadSpec(t) {
+		for _, p := range parsers {
+			got := renderer.RenderMarkdown(p.Parse(ex.Markdown), renderer.WithLossless())
+			if got != ex.Markdown {
+				t.Errorf("example %d changed\nMarkdown:\n%s\nGot:\n%s",
+					ex.Example, showWhitespace(ex.Markdown), showWhitespace(got),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package toc builds a table of contents from the headings of a document.
+package toc
+
+import (
+	"fmt"
+	"github.com/rybkr/markee/ast"
+	"strings"
+	"unicode"
+)
+
+// Entry is a single heading in a document outline.
+type Entry struct {
+	Level    int      `json:"level"`
+	Text     string   `json:"text"`
+	ID       string   `json:"id"`
+	Children []*Entry `json:"children,omitempty"`
+}
+
+// Options restricts which heading levels appear in the outline.
+type Options struct {
+	MinLevel int
+	MaxLevel int
+}
+
+func DefaultOptions() Options {
+	return Options{MinLevel: 1, MaxLevel: 6}
+}
+
+// Build collects the headings of doc into a nested outline. Entries of
+// headings without an ID get the one AssignIDs would give them, so every
+// entry can be linked to; doc itself is not changed.
+func Build(doc *ast.Document, opts Options) []*Entry {
+	ids := IDs(doc)
+
+	c := newCollector()
+	doc.Accept(c)
+
+	var roots []*Entry
+	var stack []*Entry
+	for _, heading := range c.headings {
+		if heading.Level < opts.MinLevel || heading.Level > opts.MaxLevel {
+			continue
+		}
+		entry := &Entry{
+			Level: heading.Level,
+			Text:  PlainText(heading),
+			ID:    ids[heading],
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+	}
+	return roots
+}
+
+// AssignIDs gives every heading without an ID a slug derived from its text.
+// IDs are unique within the document; existing IDs, including one set as an
+// "id" attribute, are kept as they are.
+func AssignIDs(doc *ast.Document) {
+	for heading, id := range IDs(doc) {
+		heading.ID = id
+	}
+}
+
+// IDs returns the ID of every heading in doc as AssignIDs would set it,
+// without changing doc.
+func IDs(doc *ast.Document) map[*ast.Heading]string {
+	c := newCollector()
+	doc.Accept(c)
+
+	ids := make(map[*ast.Heading]string, len(c.headings))
+	used := make(map[string]int)
+	for _, heading := range c.headings {
+		id := heading.ID
+		if attr, ok := heading.Attr("id"); ok && id == "" {
+			id = attr
+		}
+		if id != "" {
+			ids[heading] = id
+			used[id]++
+		}
+	}
+
+	for _, heading := range c.headings {
+		if _, ok := ids[heading]; ok {
+			continue
+		}
+		base := Slugify(PlainText(heading))
+		if base == "" {
+			base = "section"
+		}
+		id := base
+		for n := 1; used[id] > 0; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id]++
+		ids[heading] = id
+	}
+	return ids
+}
+
+// Slugify turns heading text into an anchor name the way GitHub does: letters
+// and digits are lowercased, spaces become hyphens and punctuation is dropped.
+func Slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		case r == ' ' || r == '-':
+			b.WriteByte('-')
+		case r == '_':
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// PlainText returns the text of an inline container with all markup removed.
+func PlainText(node ast.Node) string {
+	var b strings.Builder
+	writePlainText(&b, node)
+	return b.String()
+}
+
+func writePlainText(b *strings.Builder, node ast.Node) {
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Content:
+			b.WriteString(n.Literal)
+		case *ast.CodeSpan:
+			b.WriteString(n.Literal)
+		case *ast.Image:
+			b.WriteString(n.AltText)
+		case *ast.SoftBreak, *ast.LineBreak:
+			b.WriteByte(' ')
+		default:
+			writePlainText(b, child)
+		}
+	}
+}
+
+type collector struct {
+	ast.BaseVisitor
+	headings []*ast.Heading
+}
+
+func newCollector() *collector {
+	return &collector{}
+}
+
+func (c *collector) VisitDocument(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *collector) VisitBlockQuote(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *collector) VisitList(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *collector) VisitListItem(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *collector) VisitFencedDiv(node ast.Node) {
+	ast.WalkChildren(c, node)
+}
+
+func (c *collector) VisitNode(node ast.Node) {
+	if node.Type().IsContainer() {
+		ast.WalkChildren(c, node)
+	}
+}
+
+func (c *collector) VisitHeading(node ast.Node) {
+	if heading, ok := node.(*ast.Heading); ok {
+		c.headings = append(c.headings, heading)
+	}
+}
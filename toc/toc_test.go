@@ -0,0 +1,100 @@
+package toc
+
+import (
+	"github.com/rybkr/markee/ast"
+	"github.com/rybkr/markee/parser"
+	"testing"
+)
+
+func TestBuildNestsByLevel(t *testing.T) {
+	input := `# Intro
+## Install
+### From source
+## Usage
+# Reference`
+	entries := Build(parser.Parse(input), DefaultOptions())
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 top-level entries, got %d", len(entries))
+	}
+	intro := entries[0]
+	if intro.Text != "Intro" || intro.ID != "intro" || len(intro.Children) != 2 {
+		t.Errorf("unexpected intro entry %+v", intro)
+	}
+	if install := intro.Children[0]; len(install.Children) != 1 || install.Children[0].ID != "from-source" {
+		t.Errorf("expected install to contain from-source, got %+v", install)
+	}
+	if entries[1].Text != "Reference" {
+		t.Errorf("expected second entry Reference, got %q", entries[1].Text)
+	}
+}
+
+func TestBuildLevelRange(t *testing.T) {
+	input := `# Title
+## One
+### Deep
+## Two`
+	entries := Build(parser.Parse(input), Options{MinLevel: 2, MaxLevel: 2})
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Level != 2 || len(entry.Children) != 0 {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+}
+
+func TestAssignIDsUnique(t *testing.T) {
+	input := `# Setup
+# Setup
+# Setup`
+	entries := Build(parser.Parse(input), DefaultOptions())
+
+	expected := []string{"setup", "setup-1", "setup-2"}
+	for i, entry := range entries {
+		if entry.ID != expected[i] {
+			t.Errorf("entry %d: expected id %q, got %q", i, expected[i], entry.ID)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":        "hello-world",
+		"What's new in 2.0?": "whats-new-in-20",
+		"snake_case-name":    "snake_case-name",
+		"  padded  ":         "padded",
+	}
+	for input, expected := range tests {
+		if got := Slugify(input); got != expected {
+			t.Errorf("Slugify(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+}
+
+func TestAssignIDsUsesIDAttribute(t *testing.T) {
+	doc := parser.Parse("# Setup\n# Setup")
+	doc.FirstChild().SetAttr("id", "setup")
+
+	entries := Build(doc, DefaultOptions())
+	if entries[0].ID != "setup" || entries[1].ID != "setup-1" {
+		t.Errorf("unexpected ids %q, %q", entries[0].ID, entries[1].ID)
+	}
+}
+
+func TestBuildLeavesDocumentUnchanged(t *testing.T) {
+	doc := parser.Parse("# Setup\n## Usage")
+	Build(doc, DefaultOptions())
+	for heading := range ast.OfType[*ast.Heading](ast.All(doc)) {
+		if heading.ID != "" {
+			t.Errorf("expected Build not to set ids, got %q", heading.ID)
+		}
+	}
+
+	AssignIDs(doc)
+	if id := doc.FirstChild().(*ast.Heading).ID; id != "setup" {
+		t.Errorf("expected AssignIDs to set the id, got %q", id)
+	}
+}